@@ -0,0 +1,116 @@
+package streamload
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"regexp"
+)
+
+// AddressMapper translates the BE address of a redirect location into one
+// reachable by the client, e.g. when BEs advertise internal pod or NAT IPs
+type AddressMapper interface {
+	// MapAddress receives the host:port of the redirect location and returns the address to use
+	MapAddress(addr string) (string, error)
+}
+
+// AddressMapperFunc adapts a function to the AddressMapper interface
+type AddressMapperFunc func(addr string) (string, error)
+
+// MapAddress calls f(addr)
+func (f AddressMapperFunc) MapAddress(addr string) (string, error) {
+	return f(addr)
+}
+
+// StaticAddressMapper maps addresses using a lookup table.
+// Keys are matched against host:port first and then against the host alone,
+// in which case the original port is kept unless the value has its own port.
+// Unknown addresses are returned unchanged.
+func StaticAddressMapper(mapping map[string]string) AddressMapper {
+	return AddressMapperFunc(func(addr string) (string, error) {
+		if mapped, ok := mapping[addr]; ok {
+			return mapped, nil
+		}
+
+		host, port, err := net.SplitHostPort(addr)
+		if err != nil {
+			return addr, nil
+		}
+		mapped, ok := mapping[host]
+		if !ok {
+			return addr, nil
+		}
+		if _, _, err := net.SplitHostPort(mapped); err == nil {
+			return mapped, nil
+		}
+		return net.JoinHostPort(mapped, port), nil
+	})
+}
+
+// RegexAddressMapper rewrites addresses matching pattern using replacement,
+// which may reference capture groups as in regexp.Regexp.ReplaceAllString.
+// Addresses that do not match are returned unchanged.
+func RegexAddressMapper(pattern, replacement string) (AddressMapper, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid address pattern: %w", err)
+	}
+	return AddressMapperFunc(func(addr string) (string, error) {
+		return re.ReplaceAllString(addr, replacement), nil
+	}), nil
+}
+
+// SetAddressMapper sets the mapper applied to every redirect location returned by the FE
+func (c *Client) SetAddressMapper(mapper AddressMapper) {
+	c.addressMapper = mapper
+}
+
+// SetProxy routes all FE and BE traffic through the given HTTP proxy, e.g. "http://proxy:3128".
+// The proxy is installed on the transport of the current HTTP client, so call SetProxy after SetHTTPClient.
+func (c *Client) SetProxy(proxyURL string) error {
+	parsed, err := url.Parse(proxyURL)
+	if err != nil {
+		return fmt.Errorf("invalid proxy URL: %w", err)
+	}
+	if parsed.Scheme == "" || parsed.Host == "" {
+		return fmt.Errorf("invalid proxy URL %q: scheme and host are required", proxyURL)
+	}
+
+	transport, err := c.cloneTransport()
+	if err != nil {
+		return err
+	}
+	transport.Proxy = http.ProxyURL(parsed)
+
+	httpClient := *c.httpClient
+	httpClient.Transport = transport
+	c.httpClient = &httpClient
+	return nil
+}
+
+// mapLocation applies the address mapper to a redirect location
+func (c *Client) mapLocation(location string) (string, error) {
+	if c.addressMapper == nil {
+		return location, nil
+	}
+
+	parsed, err := url.Parse(location)
+	if err != nil {
+		return "", fmt.Errorf("invalid redirect location %q: %w", location, err)
+	}
+
+	addr, err := c.addressMapper.MapAddress(parsed.Host)
+	if err != nil {
+		return "", fmt.Errorf("failed to map address %s: %w", parsed.Host, err)
+	}
+	if addr == parsed.Host {
+		return location, nil
+	}
+
+	if c.logger != nil {
+		c.logger.Printf("[DEBUG] Mapped redirect address %s to %s", parsed.Host, addr)
+	}
+	parsed.Host = addr
+	return parsed.String(), nil
+}
@@ -0,0 +1,76 @@
+package streamload
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestStaticAddressMapper(t *testing.T) {
+	mapper := StaticAddressMapper(map[string]string{
+		"10.0.0.1:8040": "be1.example.com:18040",
+		"10.0.0.2":      "be2.example.com",
+	})
+
+	cases := map[string]string{
+		"10.0.0.1:8040": "be1.example.com:18040",
+		"10.0.0.2:8040": "be2.example.com:8040",
+		"10.0.0.3:8040": "10.0.0.3:8040",
+	}
+	for addr, want := range cases {
+		got, err := mapper.MapAddress(addr)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got != want {
+			t.Errorf("%s: expected %s, got %s", addr, want, got)
+		}
+	}
+}
+
+func TestRegexAddressMapper(t *testing.T) {
+	mapper, err := RegexAddressMapper(`^10\.0\.0\.(\d+):8040$`, "be-$1.example.com:8040")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got, _ := mapper.MapAddress("10.0.0.7:8040")
+	if got != "be-7.example.com:8040" {
+		t.Errorf("expected be-7.example.com:8040, got %s", got)
+	}
+
+	if _, err := RegexAddressMapper("(", ""); err == nil {
+		t.Error("expected an error for an invalid pattern")
+	}
+}
+
+func TestLoad_MapsRedirectLocation(t *testing.T) {
+	be := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"Status":"Success"}`))
+	}))
+	defer be.Close()
+	beAddr := strings.TrimPrefix(be.URL, "http://")
+
+	fe := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Advertise an internal address the client cannot reach
+		http.Redirect(w, r, "http://192.0.2.10:8040"+r.URL.Path, http.StatusTemporaryRedirect)
+	}))
+	defer fe.Close()
+
+	client := newTestClient(t, fe)
+	client.SetAddressMapper(StaticAddressMapper(map[string]string{"192.0.2.10:8040": beAddr}))
+
+	if _, err := client.Load("users", strings.NewReader("1,a"), LoadOptions{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestSetProxy_InvalidURL(t *testing.T) {
+	client := NewClient("localhost", "8030", "test_db", "root", "")
+	if err := client.SetProxy("proxy:3128"); err == nil {
+		t.Error("expected an error for a proxy URL without scheme")
+	}
+	if err := client.SetProxy("http://proxy:3128"); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
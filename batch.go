@@ -0,0 +1,427 @@
+package streamload
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrWriterClosed is returned when writing to a closed BatchWriter
+var ErrWriterClosed = errors.New("batch writer is closed")
+
+// BatchConfig configures a BatchWriter
+type BatchConfig struct {
+	// MaxRows flushes a batch once it holds this many rows, 0 means no row limit
+	MaxRows int
+	// MaxBytes flushes a batch once it holds this many bytes, defaults to 16 MiB
+	MaxBytes int
+	// FlushInterval flushes a non-empty batch at this interval, 0 disables time-based flushing
+	FlushInterval time.Duration
+	// MaxBufferedBytes bounds the bytes held by buffered and in-flight batches. Write blocks
+	// while the limit is reached. Defaults to 4 * MaxBytes.
+	MaxBufferedBytes int
+	// Concurrency is the number of batches loaded concurrently, defaults to 1
+	Concurrency int
+	// OnFlush is called with the result of every batch load. It may be called
+	// concurrently if Concurrency is greater than 1.
+	OnFlush func(FlushResult)
+	// DeadLetter stores batches whose load failed, so they can be replayed with
+	// ReplayDeadLetters. Every batch is given a label if opts.Label is empty.
+	DeadLetter *DeadLetterDir
+	// WAL appends every row to a write-ahead log before Write returns, see WAL.
+	// Batches are loaded under the labels of their segments.
+	WAL *WAL
+}
+
+// FlushResult reports the outcome of loading a single batch
+type FlushResult struct {
+	Table    string
+	Label    string
+	Rows     int
+	Bytes    int
+	Response *LoadResponse
+	Err      error
+	Duration time.Duration
+	// DeadLetterID is the id of the dead-letter entry of a failed batch
+	DeadLetterID string
+}
+
+// BatchWriter accumulates rows and loads them in batches in the background.
+// Rows are encoded CSV lines or JSON objects matching opts.Format. It is safe
+// for concurrent use.
+type BatchWriter struct {
+	client *Client
+	table  string
+	opts   LoadOptions
+	config BatchConfig
+	budget *memoryBudget
+	sem    chan struct{}
+
+	mu      sync.Mutex
+	idle    *sync.Cond // Signalled when the last pending load finishes
+	batch   *batch
+	seq     int
+	closed  bool
+	pending int
+	errs    []error
+	// started is closed once the most recently dispatched batch holds a load slot,
+	// so batches of a writer start loading in the order they were dispatched
+	started chan struct{}
+
+	// pressure is called when the memory budget is exhausted, it dispatches
+	// buffered batches so that their memory is eventually released
+	pressure func()
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// batch is a batch of encoded rows being accumulated
+type batch struct {
+	buf      bytes.Buffer
+	rows     int
+	reserved int         // Bytes acquired from the memory budget
+	segment  *walSegment // WAL segment holding the rows of the batch
+}
+
+// NewBatchWriter creates a writer that loads rows into table in batches.
+// Call Close to flush the remaining rows and stop the writer.
+func (c *Client) NewBatchWriter(table string, opts LoadOptions, config BatchConfig) *BatchWriter {
+	config = config.withDefaults()
+	return c.newBatchWriter(table, opts, config, newMemoryBudget(config.MaxBufferedBytes), make(chan struct{}, config.Concurrency))
+}
+
+// withDefaults fills in the defaults of unset fields
+func (config BatchConfig) withDefaults() BatchConfig {
+	if config.MaxBytes <= 0 {
+		config.MaxBytes = 16 * 1024 * 1024
+	}
+	if config.MaxBufferedBytes <= 0 {
+		config.MaxBufferedBytes = 4 * config.MaxBytes
+	}
+	if config.Concurrency <= 0 {
+		config.Concurrency = 1
+	}
+	return config
+}
+
+// newBatchWriter creates a batch writer drawing from the given memory budget and load slots
+func (c *Client) newBatchWriter(table string, opts LoadOptions, config BatchConfig, budget *memoryBudget, sem chan struct{}) *BatchWriter {
+	// Batches are buffered in memory already, there is nothing to stream
+	opts.Streaming = false
+	if opts.Format == FormatJSON {
+		opts.StripOuterArray = true
+	}
+
+	w := &BatchWriter{
+		client: c,
+		table:  table,
+		opts:   opts,
+		config: config,
+		budget: budget,
+		sem:    sem,
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+	w.idle = sync.NewCond(&w.mu)
+	w.pressure = w.dispatch
+
+	if config.FlushInterval > 0 {
+		go w.flushLoop()
+	} else {
+		close(w.done)
+	}
+	return w
+}
+
+// Write adds an encoded row to the current batch, blocking while the memory budget is exhausted
+func (w *BatchWriter) Write(row []byte) error {
+	return w.WriteContext(context.Background(), row)
+}
+
+// WriteContext is like Write but stops waiting for memory when ctx is done
+func (w *BatchWriter) WriteContext(ctx context.Context, row []byte) error {
+	n := len(row) + len(w.delimiter())
+	if !w.budget.tryAcquire(n) {
+		// The budget may be held by batches that are not full yet, load them instead of waiting forever
+		w.pressure()
+		if err := w.budget.acquire(ctx, n); err != nil {
+			return err
+		}
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		w.budget.release(n)
+		return ErrWriterClosed
+	}
+
+	if w.batch == nil {
+		b := &batch{}
+		if w.config.WAL != nil {
+			seg, err := w.config.WAL.create(w.table, w.opts)
+			if err != nil {
+				w.budget.release(n)
+				return err
+			}
+			b.segment = seg
+		}
+		if w.opts.Format == FormatJSON {
+			b.buf.WriteByte('[')
+		}
+		w.batch = b
+	} else {
+		w.batch.buf.WriteString(w.delimiter())
+	}
+	if seg := w.batch.segment; seg != nil {
+		if err := seg.append(row); err != nil {
+			// The segment may end in a partial record now, stop appending to it
+			w.budget.release(n)
+			if w.batch.rows == 0 {
+				w.budget.release(w.batch.reserved)
+				seg.remove()
+				w.batch = nil
+			} else {
+				w.batch.buf.Truncate(w.batch.buf.Len() - len(w.delimiter()))
+				w.dispatchLocked()
+			}
+			return err
+		}
+	}
+	w.batch.buf.Write(row)
+	w.batch.rows++
+	w.batch.reserved += n
+
+	if (w.config.MaxRows > 0 && w.batch.rows >= w.config.MaxRows) || w.batch.buf.Len() >= w.config.MaxBytes {
+		w.dispatchLocked()
+	}
+	return nil
+}
+
+// Flush loads the current batch, waits for all pending loads and returns
+// the errors of the loads that failed since the previous Flush
+func (w *BatchWriter) Flush() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.dispatchLocked()
+	for w.pending > 0 {
+		w.idle.Wait()
+	}
+
+	err := errors.Join(w.errs...)
+	w.errs = nil
+	return err
+}
+
+// Close flushes the remaining rows and stops the writer. Writes after Close fail with ErrWriterClosed.
+func (w *BatchWriter) Close() error {
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return nil
+	}
+	w.closed = true
+	w.mu.Unlock()
+
+	close(w.stop)
+	<-w.done
+	return w.Flush()
+}
+
+// delimiter returns the separator written between rows
+func (w *BatchWriter) delimiter() string {
+	return rowDelimiter(w.opts)
+}
+
+// rowDelimiter returns the separator between the rows of a batch loaded with opts
+func rowDelimiter(opts LoadOptions) string {
+	if opts.Format == FormatJSON {
+		return ","
+	}
+	if opts.RowDelimiter != "" {
+		return opts.RowDelimiter
+	}
+	return "\n"
+}
+
+// flushLoop flushes the current batch every FlushInterval
+func (w *BatchWriter) flushLoop() {
+	defer close(w.done)
+	ticker := time.NewTicker(w.config.FlushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-w.stop:
+			return
+		case <-ticker.C:
+			w.dispatch()
+		}
+	}
+}
+
+// dispatch hands the current batch to a background load
+func (w *BatchWriter) dispatch() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.dispatchLocked()
+}
+
+// dispatchLocked hands the current batch to a background load, w.mu must be held
+func (w *BatchWriter) dispatchLocked() {
+	b := w.batch
+	if b == nil {
+		return
+	}
+	w.batch = nil
+	if w.opts.Format == FormatJSON {
+		b.buf.WriteByte(']')
+	}
+
+	opts := w.opts
+	if b.segment != nil {
+		// Replaying the segment after a crash must not load it twice
+		opts.Label = b.segment.label
+	} else if opts.Label != "" {
+		// A label can only be used once, derive one per batch
+		w.seq++
+		opts.Label = fmt.Sprintf("%s-%d", w.opts.Label, w.seq)
+	} else if w.config.DeadLetter != nil {
+		// A replayed batch must not be loaded twice
+		opts.Label = newLabel()
+	}
+
+	prev, started := w.started, make(chan struct{})
+	w.started = started
+
+	w.pending++
+	go func() {
+		if prev != nil {
+			<-prev
+		}
+		w.sem <- struct{}{}
+		close(started)
+		result := w.load(b, opts)
+		if w.config.OnFlush != nil {
+			w.config.OnFlush(result)
+		}
+		<-w.sem
+
+		w.mu.Lock()
+		defer w.mu.Unlock()
+		if result.Err != nil {
+			w.errs = append(w.errs, result.Err)
+		}
+		w.pending--
+		if w.pending == 0 {
+			w.idle.Broadcast()
+		}
+	}()
+}
+
+// load loads a batch and returns its memory to the budget, the caller holds a load slot
+func (w *BatchWriter) load(b *batch, opts LoadOptions) FlushResult {
+	defer w.budget.release(b.reserved)
+
+	start := time.Now()
+	var segErr error
+	if b.segment != nil {
+		segErr = b.segment.seal()
+	}
+	resp, err := w.client.LoadContext(context.Background(), w.table, bytes.NewReader(b.buf.Bytes()), opts)
+	if err != nil && w.client.logger != nil {
+		w.client.logger.Printf("[DEBUG] BatchWriter: load of %d rows into %s failed: %v", b.rows, w.table, err)
+	}
+
+	label := opts.Label
+	if resp != nil && resp.Label != "" {
+		label = resp.Label
+	}
+	result := FlushResult{
+		Table:    w.table,
+		Label:    label,
+		Rows:     b.rows,
+		Bytes:    b.buf.Len(),
+		Response: resp,
+		Err:      err,
+		Duration: time.Since(start),
+	}
+
+	if err != nil && w.config.DeadLetter != nil {
+		opts.Label = label
+		id, dlErr := w.config.DeadLetter.Write(w.table, b.buf.Bytes(), b.rows, opts, err)
+		if dlErr != nil {
+			result.Err = errors.Join(err, dlErr)
+		}
+		result.DeadLetterID = id
+	}
+
+	if b.segment != nil {
+		if result.Err == nil || result.DeadLetterID != "" {
+			segErr = errors.Join(segErr, b.segment.remove())
+		} else {
+			// Keep the segment so ReplayWAL loads it again
+			b.segment.release()
+		}
+		if segErr != nil {
+			result.Err = errors.Join(result.Err, fmt.Errorf("WAL segment %s: %w", b.segment.id, segErr))
+		}
+	}
+	return result
+}
+
+// memoryBudget bounds the bytes held by batch writers
+type memoryBudget struct {
+	mu      sync.Mutex
+	limit   int
+	used    int
+	changed chan struct{}
+}
+
+func newMemoryBudget(limit int) *memoryBudget {
+	return &memoryBudget{limit: limit, changed: make(chan struct{})}
+}
+
+// tryAcquire reserves n bytes if they are available without waiting
+func (b *memoryBudget) tryAcquire(n int) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.used == 0 || b.used+n <= b.limit {
+		b.used += n
+		return true
+	}
+	return false
+}
+
+// acquire reserves n bytes, waiting until they are available. A request larger
+// than the limit is admitted once nothing else is reserved.
+func (b *memoryBudget) acquire(ctx context.Context, n int) error {
+	for {
+		b.mu.Lock()
+		if b.used == 0 || b.used+n <= b.limit {
+			b.used += n
+			b.mu.Unlock()
+			return nil
+		}
+		changed := b.changed
+		b.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-changed:
+		}
+	}
+}
+
+// release returns n bytes to the budget and wakes up waiting writers
+func (b *memoryBudget) release(n int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.used -= n
+	close(b.changed)
+	b.changed = make(chan struct{})
+}
@@ -0,0 +1,122 @@
+package streamload
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recordingServer records the body of every load and answers with Success
+func recordingServer(bodies *[]string, mu *sync.Mutex) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		*bodies = append(*bodies, string(body))
+		mu.Unlock()
+		w.Write([]byte(`{"Status":"Success"}`))
+	}))
+}
+
+func TestBatchWriter_MaxRows(t *testing.T) {
+	var mu sync.Mutex
+	var bodies []string
+	server := recordingServer(&bodies, &mu)
+	defer server.Close()
+
+	var results []FlushResult
+	client := newTestClient(t, server)
+	writer := client.NewBatchWriter("users", LoadOptions{Format: FormatCSV, Label: "batch"}, BatchConfig{
+		MaxRows: 2,
+		OnFlush: func(result FlushResult) {
+			mu.Lock()
+			results = append(results, result)
+			mu.Unlock()
+		},
+	})
+
+	for _, row := range []string{"1,a", "2,b", "3,c"} {
+		if err := writer.Write([]byte(row)); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(bodies) != 2 || bodies[0] != "1,a\n2,b" || bodies[1] != "3,c" {
+		t.Errorf("unexpected batches %q", bodies)
+	}
+	if len(results) != 2 || results[0].Label != "batch-1" || results[0].Rows != 2 || results[1].Label != "batch-2" {
+		t.Errorf("unexpected results %+v", results)
+	}
+	if err := writer.Write([]byte("4,d")); !errors.Is(err, ErrWriterClosed) {
+		t.Errorf("expected ErrWriterClosed, got %v", err)
+	}
+}
+
+func TestBatchWriter_JSONAndFlushInterval(t *testing.T) {
+	var mu sync.Mutex
+	var bodies []string
+	server := recordingServer(&bodies, &mu)
+	defer server.Close()
+
+	client := newTestClient(t, server)
+	writer := client.NewBatchWriter("users", LoadOptions{Format: FormatJSON}, BatchConfig{FlushInterval: 20 * time.Millisecond})
+	defer writer.Close()
+
+	writer.Write([]byte(`{"id":1}`))
+	writer.Write([]byte(`{"id":2}`))
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		mu.Lock()
+		n := len(bodies)
+		mu.Unlock()
+		if n > 0 || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(bodies) != 1 || bodies[0] != `[{"id":1},{"id":2}]` {
+		t.Errorf("unexpected batches %q", bodies)
+	}
+}
+
+func TestBatchWriter_Backpressure(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+		<-release
+		w.Write([]byte(`{"Status":"Success"}`))
+	}))
+	defer server.Close()
+
+	client := newTestClient(t, server)
+	writer := client.NewBatchWriter("users", LoadOptions{}, BatchConfig{MaxBytes: 4, MaxBufferedBytes: 10})
+
+	// Two full batches exhaust the budget while their loads hang
+	writer.Write([]byte("aaaa"))
+	writer.Write([]byte("bbbb"))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := writer.WriteContext(ctx, []byte("cccc")); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected the write to block until the deadline, got %v", err)
+	}
+
+	close(release)
+	if err := writer.Write([]byte("dddd")); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
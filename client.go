@@ -1,10 +1,14 @@
 package streamload
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
+	"io"
 	"log"
 	"net/http"
 	"net/url"
+	"strings"
 	"sync"
 	"time"
 )
@@ -19,7 +23,19 @@ type Client struct {
 	password       string
 	defaultHeader  map[string]string
 	logger         *log.Logger
+	retryPolicy    RetryPolicy
+	tlsEnabled     bool
+	addressMapper  AddressMapper
+	spool          SpoolConfig
+	endpoints      []*endpointState
+	health         *healthChecker
 	mu             sync.RWMutex
+
+	// txns tracks the tables and load statistics of the transactions begun by this client
+	txns   map[string]*txnTables
+	txnsMu sync.Mutex
+
+	journal *TransactionJournal
 }
 
 // NewClient creates a new StarRocks stream load client with a single FE endpoint
@@ -32,6 +48,10 @@ func NewClientWithFEs(fes []FEEndpoint, database, username, password string) *Cl
 	if len(fes) == 0 {
 		panic("at least one FE endpoint is required")
 	}
+	endpoints := make([]*endpointState, len(fes))
+	for i := range endpoints {
+		endpoints[i] = &endpointState{}
+	}
 	return &Client{
 		httpClient: &http.Client{
 			Timeout: 30 * time.Minute,
@@ -47,15 +67,29 @@ func NewClientWithFEs(fes []FEEndpoint, database, username, password string) *Cl
 		password:       password,
 		defaultHeader:  make(map[string]string),
 		logger:         nil,
+		endpoints:      endpoints,
 	}
 }
 
 // getCurrentFEURL returns the current FE URL using round-robin selection
 func (c *Client) getCurrentFEURL() string {
 	c.mu.RLock()
-	defer c.mu.RUnlock()
-	fe := c.fes[c.currentFEIndex]
-	return fmt.Sprintf("http://%s:%s", fe.Host, fe.Port)
+	idx := c.currentFEIndex
+	c.mu.RUnlock()
+	return c.feURL(idx)
+}
+
+// feURL returns the base URL of the FE at the given index
+func (c *Client) feURL(idx int) string {
+	fe := c.fes[idx]
+	scheme := fe.Scheme
+	if scheme == "" {
+		scheme = "http"
+		if c.tlsEnabled {
+			scheme = "https"
+		}
+	}
+	return fmt.Sprintf("%s://%s:%s", scheme, fe.Host, fe.Port)
 }
 
 // nextFE rotates to the next FE endpoint
@@ -71,13 +105,22 @@ func (c *Client) doRequest(req *http.Request) (*http.Response, error) {
 	var lastErr error
 
 	for i := 0; i < maxRetries; i++ {
-		feURL := c.getCurrentFEURL()
+		feIndex := c.selectFE()
+		feURL := c.feURL(feIndex)
 		parsedFEURL, err := url.Parse(feURL)
 		if err != nil {
 			return nil, fmt.Errorf("failed to parse FE URL: %w", err)
 		}
 
 		retryReq := req.Clone(req.Context())
+		// The previous attempt may have consumed the body, take a fresh copy
+		if i > 0 && req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, fmt.Errorf("failed to rewind request body: %w", err)
+			}
+			retryReq.Body = body
+		}
 		retryReq.URL.Scheme = parsedFEURL.Scheme
 		retryReq.URL.Host = parsedFEURL.Host
 		retryReq.URL.Path = parsedFEURL.Path + req.URL.Path
@@ -92,12 +135,19 @@ func (c *Client) doRequest(req *http.Request) (*http.Response, error) {
 			if c.logger != nil {
 				c.logger.Printf("[DEBUG] Success: Connected to %s", targetURL)
 			}
+			c.recordSuccess(feIndex)
 			return resp, nil
 		}
 
 		if c.logger != nil {
 			c.logger.Printf("[DEBUG] Failed: %s - Error: %v", targetURL, err)
 		}
+		// A cancelled or expired context is not an FE failure, stop failing over
+		if req.Context().Err() != nil {
+			c.releaseTrial(feIndex)
+			return nil, err
+		}
+		c.recordFailure(feIndex, err)
 		lastErr = err
 		c.nextFE()
 	}
@@ -105,6 +155,239 @@ func (c *Client) doRequest(req *http.Request) (*http.Response, error) {
 	return nil, lastErr
 }
 
+// rawResponse is the final response of a round trip
+type rawResponse struct {
+	statusCode int
+	body       []byte
+	endpoint   string // Base URL of the FE or BE that answered
+}
+
+// roundTrip sends a request to the FE with failover, follows a 307 redirect
+// from the FE to the BE and returns the final response. The body of a streamed
+// payload is only sent to the FE once it answered "100 Continue".
+func (c *Client) roundTrip(ctx context.Context, method, path string, headers map[string]string, body payload) (*rawResponse, error) {
+	newRequest := func(ctx context.Context, urlStr string, gate *continueGate) (*http.Request, error) {
+		open := func() (io.Reader, error) {
+			r, err := body.open()
+			if err != nil || gate == nil {
+				return r, err
+			}
+			return gate.reader(r), nil
+		}
+		var reader io.Reader
+		if body != nil {
+			r, err := open()
+			if err != nil {
+				return nil, err
+			}
+			reader = r
+		}
+		req, err := http.NewRequestWithContext(ctx, method, urlStr, reader)
+		if err != nil {
+			return nil, err
+		}
+		if body != nil {
+			// -1 sends the body with chunked transfer encoding
+			req.ContentLength = body.size()
+			if req.ContentLength == 0 {
+				req.Body = http.NoBody
+			}
+			req.GetBody = func() (io.ReadCloser, error) {
+				r, err := open()
+				if err != nil {
+					return nil, err
+				}
+				return io.NopCloser(r), nil
+			}
+		}
+		req.SetBasicAuth(c.username, c.password)
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+		return req, nil
+	}
+
+	// A streamed body must reach the BE, hold it back until the FE asks for it
+	var gate *continueGate
+	feCtx := ctx
+	if _, ok := body.(*streamPayload); ok {
+		gate = newContinueGate()
+		feCtx = gate.trace(ctx)
+	}
+	req, err := newRequest(feCtx, c.getCurrentFEURL()+path, gate)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := c.doRequest(req)
+	if gate != nil {
+		gate.close()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+
+	// Handle 307 Temporary Redirect from FE to BE
+	if resp.StatusCode == http.StatusTemporaryRedirect {
+		location := resp.Header.Get("Location")
+		resp.Body.Close()
+		if location == "" {
+			return nil, fmt.Errorf("received 307 redirect without Location header")
+		}
+		location, err = c.mapLocation(location)
+		if err != nil {
+			return nil, err
+		}
+
+		redirectReq, err := newRequest(ctx, location, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create redirect request: %w", err)
+		}
+
+		resp, err = c.httpClient.Do(redirectReq)
+		if err != nil {
+			return nil, fmt.Errorf("failed to send redirect request: %w", err)
+		}
+	}
+	defer resp.Body.Close()
+
+	raw := &rawResponse{
+		statusCode: resp.StatusCode,
+		endpoint:   resp.Request.URL.Scheme + "://" + resp.Request.URL.Host,
+	}
+	raw.body, err = io.ReadAll(resp.Body)
+	if err != nil {
+		return raw, fmt.Errorf("failed to read response body: %w", err)
+	}
+	return raw, nil
+}
+
+// statusResponse is implemented by every StarRocks response type
+type statusResponse interface {
+	info() responseInfo
+}
+
+// responseInfo holds the fields shared by the StarRocks response types
+type responseInfo struct {
+	Status            string
+	Message           string
+	TxnId             int64
+	ErrorURL          string
+	ExistingJobStatus string
+}
+
+// call performs op under the client's retry policy and decodes the JSON response.
+// A response is considered failed if the HTTP status is not 200 or, when okStatus
+// is not empty, if the StarRocks Status field differs from okStatus. Failures
+// reported by StarRocks are returned as *LoadError.
+//
+// A retry that finds its label already used by a finished job succeeds: the label
+// is reused across attempts, so the job is an earlier attempt that was committed
+// although it reported a failure such as "Publish Timeout".
+func call[T any, PT interface {
+	*T
+	statusResponse
+}](c *Client, ctx context.Context, op, method, path string, headers map[string]string, body payload, okStatus string) (*T, error) {
+	var out *T
+	attempts := 0
+	attempt := func() AttemptResult {
+		out = nil
+		attempts++
+		raw, err := c.roundTrip(ctx, method, path, headers, body)
+		if err != nil {
+			result := AttemptResult{Err: err}
+			if raw != nil {
+				result.StatusCode = raw.statusCode
+			}
+			return result
+		}
+
+		if c.logger != nil {
+			c.logger.Printf("[DEBUG] %s: Response body = %s", op, string(raw.body))
+		}
+
+		loadErr := &LoadError{
+			Op:         op,
+			StatusCode: raw.statusCode,
+			Label:      headers["label"],
+			Endpoint:   raw.endpoint,
+		}
+
+		var parsed T
+		if err := json.Unmarshal(raw.body, &parsed); err != nil {
+			// Errors such as authentication failures may come without a JSON body
+			if raw.statusCode != http.StatusOK {
+				loadErr.Message = strings.TrimSpace(string(raw.body))
+				loadErr.Err = err
+				return AttemptResult{StatusCode: raw.statusCode, Message: loadErr.Message, Err: loadErr}
+			}
+			return AttemptResult{StatusCode: raw.statusCode, Err: fmt.Errorf("failed to parse response: %w", err)}
+		}
+		out = &parsed
+
+		info := PT(out).info()
+		result := AttemptResult{StatusCode: raw.statusCode, Status: info.Status, Message: info.Message}
+		if attempts > 1 && raw.statusCode == http.StatusOK && committedByEarlierAttempt(info) {
+			if c.logger != nil {
+				c.logger.Printf("[DEBUG] %s: label %s was committed by an earlier attempt", op, headers["label"])
+			}
+			return result
+		}
+		if raw.statusCode != http.StatusOK || (okStatus != "" && info.Status != okStatus) {
+			loadErr.Status = info.Status
+			loadErr.Message = info.Message
+			loadErr.TxnId = info.TxnId
+			loadErr.ErrorURL = info.ErrorURL
+			result.Err = loadErr
+		}
+		return result
+	}
+
+	err := c.withRetry(ctx, op, func() AttemptResult {
+		result := attempt()
+		// A streamed body that was already sent cannot be retried
+		if result.Err != nil && body != nil && !body.replayable() {
+			result.final = true
+		}
+		return result
+	})
+	return out, err
+}
+
+// committedByEarlierAttempt reports whether a response rejects the label because a
+// finished job already used it
+func committedByEarlierAttempt(info responseInfo) bool {
+	if info.Status != "Label Already Exists" {
+		return false
+	}
+	switch info.ExistingJobStatus {
+	case "FINISHED", "VISIBLE", "COMMITTED":
+		return true
+	}
+	return false
+}
+
+// contextReader wraps a reader so that reads fail once the context is done
+type contextReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+// newContextReader returns a reader that stops with ctx.Err() after ctx is done
+func newContextReader(ctx context.Context, r io.Reader) io.Reader {
+	if ctx.Done() == nil {
+		return r
+	}
+	return &contextReader{ctx: ctx, r: r}
+}
+
+func (r *contextReader) Read(p []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return r.r.Read(p)
+}
+
 // SetHTTPClient sets a custom HTTP client
 func (c *Client) SetHTTPClient(client *http.Client) {
 	c.httpClient = client
@@ -115,6 +398,12 @@ func (c *Client) SetDefaultHeader(key, value string) {
 	c.defaultHeader[key] = value
 }
 
+// SetRetryPolicy sets the retry policy applied to stream loads and all
+// transaction endpoints. By default each request is attempted once.
+func (c *Client) SetRetryPolicy(policy RetryPolicy) {
+	c.retryPolicy = policy
+}
+
 // SetLogger sets a custom logger for debugging
 func (c *Client) SetLogger(logger *log.Logger) {
 	c.logger = logger
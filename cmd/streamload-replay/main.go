@@ -0,0 +1,94 @@
+// Command streamload-replay re-submits the entries of a dead-letter directory
+// written by streamload and removes those that were loaded.
+//
+// Usage:
+//
+//	streamload-replay -fe 127.0.0.1:8030 -db test -user root -dir /var/lib/app/deadletter
+//
+// The password is read from the STARROCKS_PASSWORD environment variable unless
+// -password is given. The exit status is 1 if any entry failed again.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"os/signal"
+	"strings"
+
+	"github.com/vearne/streamload"
+)
+
+func main() {
+	fes := flag.String("fe", "127.0.0.1:8030", "comma-separated FE addresses (host:port)")
+	database := flag.String("db", "", "database name")
+	username := flag.String("user", "root", "user name")
+	password := flag.String("password", os.Getenv("STARROCKS_PASSWORD"), "password, defaults to $STARROCKS_PASSWORD")
+	dir := flag.String("dir", "", "dead-letter directory")
+	retries := flag.Int("retries", 3, "attempts per entry")
+	dryRun := flag.Bool("dry-run", false, "list the entries without replaying them")
+	verbose := flag.Bool("v", false, "log requests")
+	flag.Parse()
+
+	if *database == "" || *dir == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	var endpoints []streamload.FEEndpoint
+	for _, addr := range strings.Split(*fes, ",") {
+		host, port, err := net.SplitHostPort(strings.TrimSpace(addr))
+		if err != nil {
+			log.Fatalf("invalid FE address %q: %v", addr, err)
+		}
+		endpoints = append(endpoints, streamload.FEEndpoint{Host: host, Port: port})
+	}
+
+	deadLetters, err := streamload.OpenDeadLetterDir(*dir)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if *dryRun {
+		entries, err := deadLetters.List()
+		if err != nil {
+			log.Fatal(err)
+		}
+		for _, entry := range entries {
+			fmt.Printf("%s\t%s\t%s\t%d bytes\t%s\n", entry.ID, entry.Table, entry.Label, entry.Bytes, entry.Error)
+		}
+		return
+	}
+
+	client := streamload.NewClientWithFEs(endpoints, *database, *username, *password)
+	policy := streamload.DefaultRetryPolicy()
+	policy.MaxAttempts = *retries
+	client.SetRetryPolicy(policy)
+	if *verbose {
+		client.SetLogger(log.New(os.Stderr, "", log.LstdFlags))
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	results, err := client.ReplayDeadLetters(ctx, deadLetters)
+	failed := 0
+	for _, result := range results {
+		if result.Err != nil {
+			failed++
+			fmt.Printf("FAILED\t%s\t%s\t%v\n", result.Entry.ID, result.Entry.Table, result.Err)
+			continue
+		}
+		fmt.Printf("OK\t%s\t%s\n", result.Entry.ID, result.Entry.Table)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("replayed %d entries, %d failed\n", len(results)-failed, failed)
+	if failed > 0 {
+		os.Exit(1)
+	}
+}
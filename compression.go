@@ -3,6 +3,7 @@ package streamload
 import (
 	"bytes"
 	"compress/gzip"
+	"fmt"
 	"io"
 
 	"github.com/dsnet/compress/bzip2"
@@ -12,74 +13,41 @@ import (
 
 // compressData compresses the data reader based on compression type
 func (c *Client) compressData(data io.Reader, compression CompressionType) (io.Reader, error) {
-	switch compression {
-	case CompressionGZIP:
-		return c.compressGZIP(data)
-	case CompressionLZ4:
-		return c.compressLZ4(data)
-	case CompressionZSTD:
-		return c.compressZSTD(data)
-	case CompressionBZIP2:
-		return c.compressBZIP2(data)
-	default:
+	if compression == CompressionNone {
 		return data, nil
 	}
-}
 
-// compressGZIP compresses data using GZIP
-func (c *Client) compressGZIP(data io.Reader) (io.Reader, error) {
 	var buf bytes.Buffer
-	writer := gzip.NewWriter(&buf)
-	if _, err := io.Copy(writer, data); err != nil {
-		return nil, err
-	}
-	if err := writer.Close(); err != nil {
+	if err := c.compressTo(&buf, data, compression); err != nil {
 		return nil, err
 	}
 	return &buf, nil
 }
 
-// compressLZ4 compresses data using LZ4
-func (c *Client) compressLZ4(data io.Reader) (io.Reader, error) {
-	var buf bytes.Buffer
-	writer := lz4.NewWriter(&buf)
-	if _, err := io.Copy(writer, data); err != nil {
-		return nil, err
-	}
-	if err := writer.Close(); err != nil {
-		return nil, err
-	}
-	return &buf, nil
-}
-
-// compressZSTD compresses data using ZSTD
-func (c *Client) compressZSTD(data io.Reader) (io.Reader, error) {
-	var buf bytes.Buffer
-	encoder, err := zstd.NewWriter(&buf)
+// compressTo compresses data into w based on compression type
+func (c *Client) compressTo(w io.Writer, data io.Reader, compression CompressionType) error {
+	writer, err := newCompressWriter(w, compression)
 	if err != nil {
-		return nil, err
+		return err
 	}
-	if _, err := io.Copy(encoder, data); err != nil {
-		return nil, err
-	}
-	if err := encoder.Close(); err != nil {
-		return nil, err
+	if _, err := io.Copy(writer, data); err != nil {
+		return err
 	}
-	return &buf, nil
+	return writer.Close()
 }
 
-// compressBZIP2 compresses data using BZIP2
-func (c *Client) compressBZIP2(data io.Reader) (io.Reader, error) {
-	var buf bytes.Buffer
-	writer, err := bzip2.NewWriter(&buf, &bzip2.WriterConfig{})
-	if err != nil {
-		return nil, err
-	}
-	if _, err := io.Copy(writer, data); err != nil {
-		return nil, err
-	}
-	if err := writer.Close(); err != nil {
-		return nil, err
+// newCompressWriter returns a writer that compresses into w, closing it flushes the compressed stream
+func newCompressWriter(w io.Writer, compression CompressionType) (io.WriteCloser, error) {
+	switch compression {
+	case CompressionGZIP:
+		return gzip.NewWriter(w), nil
+	case CompressionLZ4:
+		return lz4.NewWriter(w), nil
+	case CompressionZSTD:
+		return zstd.NewWriter(w)
+	case CompressionBZIP2:
+		return bzip2.NewWriter(w, &bzip2.WriterConfig{})
+	default:
+		return nil, fmt.Errorf("unsupported compression type: %s", compression)
 	}
-	return &buf, nil
 }
@@ -0,0 +1,79 @@
+package streamload
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/gocarina/gocsv"
+)
+
+// Consume drains structs from in into batched stream loads until in is closed or
+// ctx is done, then flushes the remaining rows and closes the returned channel.
+// T must be a struct or a pointer to a struct; rows are encoded and the options
+// defaulted like LoadSlice does, and batched like a BatchWriter configured with
+// config.
+//
+// The result of every batch is sent on the returned channel, which must be drained.
+// A row that cannot be encoded is skipped and reported as a result with Rows set
+// to 1 and Err set.
+func Consume[T any](ctx context.Context, c *Client, table string, in <-chan T, opts LoadOptions, config BatchConfig) (<-chan FlushResult, error) {
+	opts, err := structLoadOptions[T](opts)
+	if err != nil {
+		return nil, err
+	}
+	config = config.withDefaults()
+
+	results := make(chan FlushResult, config.Concurrency)
+	onFlush := config.OnFlush
+	config.OnFlush = func(result FlushResult) {
+		if onFlush != nil {
+			onFlush(result)
+		}
+		results <- result
+	}
+	writer := c.NewBatchWriter(table, opts, config)
+
+	go func() {
+		defer close(results)
+		// The final flush runs after ctx is done, the writer does not use it for its loads
+		defer writer.Close()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case row, ok := <-in:
+				if !ok {
+					return
+				}
+				data, err := encodeRow(row, opts.Format)
+				if err == nil {
+					err = writer.WriteContext(ctx, data)
+				}
+				if err != nil && ctx.Err() == nil {
+					results <- FlushResult{Table: table, Rows: 1, Err: err}
+				}
+			}
+		}
+	}()
+	return results, nil
+}
+
+// encodeRow encodes a single struct as a CSV line or a JSON object
+func encodeRow[T any](row T, format DataFormat) ([]byte, error) {
+	if format != FormatCSV {
+		data, err := json.Marshal(row)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal row to JSON: %w", err)
+		}
+		return data, nil
+	}
+
+	var buf bytes.Buffer
+	if err := gocsv.MarshalWithoutHeaders([]T{row}, &buf); err != nil {
+		return nil, fmt.Errorf("failed to marshal row to CSV: %w", err)
+	}
+	return bytes.TrimRight(buf.Bytes(), "\r\n"), nil
+}
@@ -0,0 +1,82 @@
+package streamload
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+func TestConsume(t *testing.T) {
+	var mu sync.Mutex
+	var bodies []string
+	server := recordingServer(&bodies, &mu)
+	defer server.Close()
+
+	client := newTestClient(t, server)
+	in := make(chan TestUser)
+	results, err := Consume(context.Background(), client, "users", in, LoadOptions{Format: FormatCSV}, BatchConfig{MaxRows: 2})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	go func() {
+		in <- TestUser{Id: 1, Name: "Alice", Age: 25}
+		in <- TestUser{Id: 2, Name: "Bob", Age: 30}
+		in <- TestUser{Id: 3, Name: "Carol", Age: 35}
+		close(in)
+	}()
+
+	var rows int
+	for result := range results {
+		if result.Err != nil {
+			t.Errorf("unexpected error: %v", result.Err)
+		}
+		rows += result.Rows
+	}
+	if rows != 3 {
+		t.Errorf("expected 3 rows, got %d", rows)
+	}
+	if len(bodies) != 2 || bodies[0] != "1,Alice,25\n2,Bob,30" || bodies[1] != "3,Carol,35" {
+		t.Errorf("unexpected batches %q", bodies)
+	}
+}
+
+func TestConsume_FlushesOnCancel(t *testing.T) {
+	var mu sync.Mutex
+	var bodies []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		bodies = append(bodies, string(body))
+		mu.Unlock()
+		w.Write([]byte(`{"Status":"Success"}`))
+	}))
+	defer server.Close()
+
+	client := newTestClient(t, server)
+	ctx, cancel := context.WithCancel(context.Background())
+	in := make(chan *TestUser)
+	results, err := Consume(ctx, client, "users", in, LoadOptions{Compression: CompressionNone, Format: FormatJSON}, BatchConfig{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	in <- &TestUser{Id: 1, Name: "Alice", Age: 25}
+	cancel()
+
+	var count int
+	for range results {
+		count++
+	}
+	if count != 1 {
+		t.Errorf("expected the final flush to report 1 batch, got %d", count)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(bodies) != 1 {
+		t.Errorf("expected the buffered row to be flushed, got %q", bodies)
+	}
+}
@@ -0,0 +1,218 @@
+package streamload
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// deadLetterVersion is the version of the dead-letter metadata format
+const deadLetterVersion = 1
+
+// DeadLetterDir stores the payloads of failed loads in a local directory so they
+// can be replayed later. Every entry consists of two files:
+//
+//	<id>.data  the uncompressed payload exactly as it was loaded
+//	<id>.json  the metadata, see DeadLetterEntry
+//
+// Entry ids start with a UTC timestamp, so sorting them orders the entries by
+// creation time. The metadata file is written last; a payload without metadata
+// is an incomplete entry and is ignored.
+type DeadLetterDir struct {
+	path string
+}
+
+// DeadLetterEntry is the metadata of a dead-letter entry, stored as JSON in <id>.json
+type DeadLetterEntry struct {
+	ID        string      `json:"-"`
+	Version   int         `json:"version"`
+	Table     string      `json:"table"`
+	Label     string      `json:"label"`
+	Options   LoadOptions `json:"options"`
+	Error     string      `json:"error"`
+	Rows      int         `json:"rows,omitempty"`
+	Bytes     int         `json:"bytes"`
+	CreatedAt time.Time   `json:"created_at"`
+}
+
+// ReplayResult reports the outcome of replaying a single dead-letter entry
+type ReplayResult struct {
+	Entry    DeadLetterEntry
+	Response *LoadResponse
+	Err      error
+}
+
+// OpenDeadLetterDir opens the dead-letter directory at path, creating it if needed
+func OpenDeadLetterDir(path string) (*DeadLetterDir, error) {
+	if err := os.MkdirAll(path, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create dead-letter directory: %w", err)
+	}
+	return &DeadLetterDir{path: path}, nil
+}
+
+// Path returns the directory of the dead-letter entries
+func (d *DeadLetterDir) Path() string {
+	return d.path
+}
+
+// Write stores a failed load and returns the id of the new entry. opts.Label should
+// hold the label of the failed load so that replaying the entry cannot load it twice.
+func (d *DeadLetterDir) Write(table string, data []byte, rows int, opts LoadOptions, loadErr error) (string, error) {
+	entry := DeadLetterEntry{
+		ID:        time.Now().UTC().Format("20060102T150405.000000000Z") + "-" + uuid.NewString()[:8],
+		Version:   deadLetterVersion,
+		Table:     table,
+		Label:     opts.Label,
+		Options:   opts,
+		Rows:      rows,
+		Bytes:     len(data),
+		CreatedAt: time.Now().UTC(),
+	}
+	if loadErr != nil {
+		entry.Error = loadErr.Error()
+	}
+	// The options of the payload as it is stored, not of the single failed attempt
+	entry.Options.Streaming = false
+	entry.Options.FetchRejectedRows = false
+
+	meta, err := json.MarshalIndent(entry, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to encode dead-letter metadata: %w", err)
+	}
+	if err := d.writeFile(entry.ID+".data", data); err != nil {
+		return "", err
+	}
+	if err := d.writeFile(entry.ID+".json", meta); err != nil {
+		os.Remove(filepath.Join(d.path, entry.ID+".data"))
+		return "", err
+	}
+	return entry.ID, nil
+}
+
+// writeFile writes a file of the entry atomically
+func (d *DeadLetterDir) writeFile(name string, data []byte) error {
+	if err := writeFileAtomic(d.path, name, data); err != nil {
+		return fmt.Errorf("failed to write dead-letter entry: %w", err)
+	}
+	return nil
+}
+
+// writeFileAtomic writes dir/name through a synced temporary file, so readers see
+// either the previous or the complete new content
+func writeFileAtomic(dir, name string, data []byte) error {
+	tmp, err := os.CreateTemp(dir, "."+name+".tmp-*")
+	if err != nil {
+		return err
+	}
+	_, err = tmp.Write(data)
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), filepath.Join(dir, name))
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+	}
+	return err
+}
+
+// List returns the complete entries ordered by creation time
+func (d *DeadLetterDir) List() ([]DeadLetterEntry, error) {
+	names, err := filepath.Glob(filepath.Join(d.path, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(names)
+
+	entries := make([]DeadLetterEntry, 0, len(names))
+	for _, name := range names {
+		data, err := os.ReadFile(name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read dead-letter entry: %w", err)
+		}
+		var entry DeadLetterEntry
+		if err := json.Unmarshal(data, &entry); err != nil {
+			return nil, fmt.Errorf("failed to parse dead-letter entry %s: %w", name, err)
+		}
+		entry.ID = strings.TrimSuffix(filepath.Base(name), ".json")
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// Remove deletes an entry
+func (d *DeadLetterDir) Remove(id string) error {
+	// Metadata first, a payload left behind is ignored by List
+	if err := os.Remove(filepath.Join(d.path, id+".json")); err != nil {
+		return err
+	}
+	if err := os.Remove(filepath.Join(d.path, id+".data")); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// ReplayDeadLetters loads every entry of dir again, oldest first, and removes the
+// entries that were loaded. An entry whose label turns out to be loaded already is
+// removed as well. Entries that fail again are kept and reported in their result;
+// the error is only set if the directory could not be read.
+func (c *Client) ReplayDeadLetters(ctx context.Context, dir *DeadLetterDir) ([]ReplayResult, error) {
+	entries, err := dir.List()
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]ReplayResult, 0, len(entries))
+	for _, entry := range entries {
+		if err := ctx.Err(); err != nil {
+			return results, err
+		}
+		result := ReplayResult{Entry: entry}
+		result.Response, result.Err = c.replayDeadLetter(ctx, dir, entry)
+		if result.Err == nil {
+			if err := dir.Remove(entry.ID); err != nil {
+				result.Err = fmt.Errorf("loaded but failed to remove dead-letter entry: %w", err)
+			}
+		}
+		if c.logger != nil {
+			c.logger.Printf("[DEBUG] Replayed dead-letter entry %s into %s: %v", entry.ID, entry.Table, result.Err)
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// replayDeadLetter loads the payload of a single entry
+func (c *Client) replayDeadLetter(ctx context.Context, dir *DeadLetterDir, entry DeadLetterEntry) (*LoadResponse, error) {
+	file, err := os.Open(filepath.Join(dir.path, entry.ID+".data"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open dead-letter payload: %w", err)
+	}
+	defer file.Close()
+
+	opts := entry.Options
+	opts.Label = entry.Label
+	resp, err := c.LoadContext(ctx, entry.Table, file, opts)
+	if err == nil || !errors.Is(err, ErrLabelAlreadyExists) {
+		return resp, err
+	}
+
+	// The original load may have succeeded after all
+	state, stateErr := c.GetLoadStateContext(ctx, entry.Label)
+	if stateErr == nil && (state == LoadStateVisible || state == LoadStateCommitted) {
+		return resp, nil
+	}
+	return resp, err
+}
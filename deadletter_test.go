@@ -0,0 +1,92 @@
+package streamload
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+)
+
+func TestDeadLetter_WriteAndReplay(t *testing.T) {
+	var fail atomic.Bool
+	fail.Store(true)
+	var replayed atomic.Value
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if fail.Load() {
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write([]byte(`{"Status":"Fail","Message":"BE is down"}`))
+			return
+		}
+		replayed.Store(r.Header.Get("label") + " " + r.Header.Get("columns") + " " + string(body))
+		w.Write([]byte(`{"Status":"Success"}`))
+	}))
+	defer server.Close()
+
+	dir, err := OpenDeadLetterDir(t.TempDir())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var result FlushResult
+	client := newTestClient(t, server)
+	writer := client.NewBatchWriter("users", LoadOptions{Columns: "id,name"}, BatchConfig{
+		DeadLetter: dir,
+		OnFlush:    func(r FlushResult) { result = r },
+	})
+	writer.Write([]byte("1,a"))
+	if err := writer.Close(); err == nil {
+		t.Fatal("expected the batch to fail")
+	}
+	if result.DeadLetterID == "" || result.Label == "" {
+		t.Fatalf("expected a labelled dead-letter entry, got %+v", result)
+	}
+
+	entries, err := dir.List()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(entries) != 1 || entries[0].ID != result.DeadLetterID || entries[0].Table != "users" ||
+		entries[0].Label != result.Label || entries[0].Rows != 1 || entries[0].Error == "" {
+		t.Fatalf("unexpected entries %+v", entries)
+	}
+
+	fail.Store(false)
+	results, err := client.ReplayDeadLetters(context.Background(), dir)
+	if err != nil || len(results) != 1 || results[0].Err != nil {
+		t.Fatalf("unexpected replay results %+v, %v", results, err)
+	}
+	if got := replayed.Load(); got != result.Label+" id,name 1,a" {
+		t.Errorf("unexpected replayed load %q", got)
+	}
+	if entries, _ := dir.List(); len(entries) != 0 {
+		t.Errorf("expected the entry to be removed, got %+v", entries)
+	}
+}
+
+func TestReplayDeadLetters_LabelAlreadyLoaded(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/test_db/get_load_state" {
+			w.Write([]byte(`{"state":"VISIBLE","status":"OK"}`))
+			return
+		}
+		w.Write([]byte(`{"Status":"Label Already Exists","ExistingJobStatus":"FINISHED"}`))
+	}))
+	defer server.Close()
+
+	dir, _ := OpenDeadLetterDir(t.TempDir())
+	if _, err := dir.Write("users", []byte("1,a"), 1, LoadOptions{Label: "lost-ack"}, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	client := newTestClient(t, server)
+	results, err := client.ReplayDeadLetters(context.Background(), dir)
+	if err != nil || len(results) != 1 || results[0].Err != nil {
+		t.Fatalf("expected the loaded entry to count as replayed, got %+v, %v", results, err)
+	}
+	if entries, _ := dir.List(); len(entries) != 0 {
+		t.Errorf("expected the entry to be removed, got %+v", entries)
+	}
+}
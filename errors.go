@@ -0,0 +1,73 @@
+package streamload
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Sentinel errors matched by *LoadError through errors.Is
+var (
+	ErrLabelAlreadyExists  = errors.New("label already exists")
+	ErrTooManyFilteredRows = errors.New("too many filtered rows")
+	ErrAuthFailed          = errors.New("authentication failed")
+	ErrTxnNotFound         = errors.New("transaction not found")
+	ErrPublishTimeout      = errors.New("publish timeout")
+)
+
+// LoadError is returned when StarRocks rejects a stream load or transaction request.
+// Use errors.Is with the sentinel errors to classify it, or errors.As to inspect it.
+type LoadError struct {
+	Op         string // Operation name, e.g. "stream load" or "commit transaction"
+	StatusCode int    // HTTP status code of the response
+	Status     string // StarRocks Status field, e.g. "Fail" or "Label Already Exists"
+	Message    string // StarRocks Message field, or the raw body of a non-JSON response
+	Label      string
+	TxnId      int64
+	Endpoint   string // Base URL of the FE or BE that answered
+	ErrorURL   string // URL of the error log of rejected rows, if any
+	Err        error  // Underlying error, e.g. when the response body was not JSON
+
+	// RejectedRows holds the parsed error log if LoadOptions.FetchRejectedRows was set
+	RejectedRows []RejectedRow
+	// RejectedElements maps RejectedRows to the input slice of LoadStructsCSV and LoadStructsJSON
+	RejectedElements []RejectedElement
+}
+
+// Error keeps the message format of earlier versions of the library
+func (e *LoadError) Error() string {
+	if e.StatusCode != http.StatusOK {
+		return fmt.Sprintf("%s failed with status %d: %s", e.Op, e.StatusCode, e.Message)
+	}
+	return fmt.Sprintf("%s failed: %s", e.Op, e.Message)
+}
+
+// Unwrap returns the underlying error
+func (e *LoadError) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether the error matches one of the sentinel errors
+func (e *LoadError) Is(target error) bool {
+	message := strings.ToLower(e.Message)
+	switch target {
+	case ErrLabelAlreadyExists:
+		return e.Status == "Label Already Exists" || e.Status == "LABEL_ALREADY_EXISTS" ||
+			strings.Contains(message, "has already been used") ||
+			strings.Contains(message, "label already exists")
+	case ErrTooManyFilteredRows:
+		return strings.Contains(message, "too many filtered rows")
+	case ErrAuthFailed:
+		return e.StatusCode == http.StatusUnauthorized ||
+			e.StatusCode == http.StatusForbidden ||
+			strings.Contains(message, "access denied") ||
+			strings.Contains(message, "authentication failed")
+	case ErrTxnNotFound:
+		return strings.Contains(e.Op, "transaction") &&
+			(strings.Contains(message, "not found") || strings.Contains(message, "not exist"))
+	case ErrPublishTimeout:
+		return e.Status == "Publish Timeout"
+	}
+	return false
+}
@@ -0,0 +1,102 @@
+package streamload
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestLoadError_Is(t *testing.T) {
+	cases := []struct {
+		name   string
+		err    *LoadError
+		target error
+	}{
+		{"label status", &LoadError{Op: "stream load", StatusCode: 200, Status: "Label Already Exists"}, ErrLabelAlreadyExists},
+		{"label message", &LoadError{Op: "begin transaction", StatusCode: 200, Message: "Label [abc] has already been used."}, ErrLabelAlreadyExists},
+		{"filtered rows", &LoadError{Op: "stream load", StatusCode: 200, Status: "Fail", Message: "too many filtered rows"}, ErrTooManyFilteredRows},
+		{"unauthorized", &LoadError{Op: "stream load", StatusCode: 401}, ErrAuthFailed},
+		{"access denied", &LoadError{Op: "stream load", StatusCode: 200, Message: "Access denied for user 'root'"}, ErrAuthFailed},
+		{"txn not found", &LoadError{Op: "commit transaction", StatusCode: 200, Message: "transaction with label abc not found"}, ErrTxnNotFound},
+		{"publish timeout", &LoadError{Op: "stream load", StatusCode: 200, Status: "Publish Timeout"}, ErrPublishTimeout},
+	}
+
+	for _, tc := range cases {
+		if !errors.Is(tc.err, tc.target) {
+			t.Errorf("%s: expected errors.Is to match %v", tc.name, tc.target)
+		}
+		if errors.Is(tc.err, errors.New("other")) {
+			t.Errorf("%s: unexpected match", tc.name)
+		}
+	}
+
+	notFound := &LoadError{Op: "stream load", StatusCode: 200, Message: "table not found"}
+	if errors.Is(notFound, ErrTxnNotFound) {
+		t.Error("a stream load error should not match ErrTxnNotFound")
+	}
+}
+
+func TestTransaction_FailedStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/transaction/begin" {
+			w.Write([]byte(`{"TxnId":-1,"Status":"LABEL_ALREADY_EXISTS","ExistingJobStatus":"RUNNING"}`))
+			return
+		}
+		w.Write([]byte(`{"Status":"FAILED","Message":"transaction with label txn-1 not found"}`))
+	}))
+	defer server.Close()
+
+	client := newTestClient(t, server)
+	if _, err := client.BeginTransaction("txn-1", []string{"users"}); !errors.Is(err, ErrLabelAlreadyExists) {
+		t.Errorf("begin: expected ErrLabelAlreadyExists, got %v", err)
+	}
+	if _, err := client.CommitTransaction("txn-1"); !errors.Is(err, ErrTxnNotFound) {
+		t.Errorf("commit: expected ErrTxnNotFound, got %v", err)
+	}
+	if _, err := client.RollbackTransaction("txn-1"); !errors.Is(err, ErrTxnNotFound) {
+		t.Errorf("rollback: expected ErrTxnNotFound, got %v", err)
+	}
+}
+
+func TestLoad_ReturnsLoadError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"TxnId":42,"Status":"Fail","Message":"too many filtered rows","ErrorURL":"http://be:8040/api/_load_error_log?file=x"}`))
+	}))
+	defer server.Close()
+
+	client := newTestClient(t, server)
+	resp, err := client.Load("users", strings.NewReader("1,a"), LoadOptions{Label: "label-1"})
+
+	var loadErr *LoadError
+	if !errors.As(err, &loadErr) {
+		t.Fatalf("expected *LoadError, got %T: %v", err, err)
+	}
+	if !errors.Is(err, ErrTooManyFilteredRows) {
+		t.Errorf("expected ErrTooManyFilteredRows, got %v", err)
+	}
+	if loadErr.Label != "label-1" || loadErr.TxnId != 42 || loadErr.Endpoint != server.URL || loadErr.ErrorURL == "" {
+		t.Errorf("unexpected error fields: %+v", loadErr)
+	}
+	if err.Error() != "stream load failed: too many filtered rows" {
+		t.Errorf("unexpected error message: %s", err.Error())
+	}
+	if resp == nil || resp.TxnId != 42 {
+		t.Errorf("expected the response to be returned with the error, got %+v", resp)
+	}
+}
+
+func TestLoad_AuthFailureWithoutJSON(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte("Unauthorized"))
+	}))
+	defer server.Close()
+
+	client := newTestClient(t, server)
+	_, err := client.Load("users", strings.NewReader("1,a"), LoadOptions{})
+	if !errors.Is(err, ErrAuthFailed) {
+		t.Fatalf("expected ErrAuthFailed, got %v", err)
+	}
+}
@@ -0,0 +1,169 @@
+package streamload
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"reflect"
+	"slices"
+
+	"github.com/gocarina/gocsv"
+)
+
+// structLoadOptions applies the defaults of the struct loaders to opts for rows of
+// type T: CSV if opts.Format is FormatCSV and JSON arrays compressed with ZSTD
+// otherwise. Unless set, Columns is derived from the tags of the format in use,
+// which csvColumnsOf and jsonColumnsOf cache per type.
+func structLoadOptions[T any](opts LoadOptions) (LoadOptions, error) {
+	typ := reflect.TypeFor[T]()
+	if opts.Format == FormatCSV {
+		if opts.Columns == "" {
+			columns, err := csvColumnsOf(typ)
+			if err != nil {
+				return opts, fmt.Errorf("failed to extract columns: %w", err)
+			}
+			opts.Columns = columns
+		}
+		if opts.ColumnSeparator == "" {
+			opts.ColumnSeparator = ","
+		}
+		return opts, nil
+	}
+
+	if opts.Columns == "" {
+		columns, err := jsonColumnsOf(typ)
+		if err != nil {
+			return opts, fmt.Errorf("failed to extract columns: %w", err)
+		}
+		opts.Columns = columns
+	}
+	opts.Format = FormatJSON
+	if opts.Compression == CompressionNone {
+		opts.Compression = CompressionZSTD
+	}
+	opts.StripOuterArray = true
+	return opts, nil
+}
+
+// LoadSlice loads a slice of structs into StarRocks, see LoadSeq
+func LoadSlice[T any](c *Client, table string, rows []T, opts LoadOptions) (*LoadResponse, error) {
+	return LoadSeqContext(context.Background(), c, table, slices.Values(rows), opts)
+}
+
+// LoadSliceContext is like LoadSlice but honors ctx for cancellation
+func LoadSliceContext[T any](ctx context.Context, c *Client, table string, rows []T, opts LoadOptions) (*LoadResponse, error) {
+	return LoadSeqContext(ctx, c, table, slices.Values(rows), opts)
+}
+
+// LoadSeq loads the structs yielded by rows into StarRocks. T must be a struct or
+// a pointer to a struct with csv or json tags.
+//
+// With opts.Format set to FormatCSV the rows are encoded like LoadStructsCSV does,
+// otherwise as a JSON array like LoadStructsJSON does, including its defaults. The
+// columns are derived from the tags of that format once per type. Rows are encoded into a pipe as the load reads
+// them, but unless opts.Streaming is set the load reads the whole encoded payload
+// into memory, or spools it, so it can be resent; memory is only bounded with
+// opts.Streaming.
+func LoadSeq[T any](c *Client, table string, rows iter.Seq[T], opts LoadOptions) (*LoadResponse, error) {
+	return LoadSeqContext(context.Background(), c, table, rows, opts)
+}
+
+// LoadSeqContext is like LoadSeq but honors ctx for cancellation
+func LoadSeqContext[T any](ctx context.Context, c *Client, table string, rows iter.Seq[T], opts LoadOptions) (*LoadResponse, error) {
+	opts, err := structLoadOptions[T](opts)
+	if err != nil {
+		return nil, err
+	}
+
+	encode := func(w io.Writer) error { return encodeJSONArray(w, rows) }
+	if opts.Format == FormatCSV {
+		encode = func(w io.Writer) error { return encodeCSV(ctx, w, rows) }
+	}
+
+	pr, pw := io.Pipe()
+	encoded := make(chan error, 1)
+	go func() {
+		err := encode(pw)
+		pw.CloseWithError(err)
+		encoded <- err
+	}()
+
+	resp, err := c.LoadContext(ctx, table, pr, opts)
+	// Stop the encoder if the load ended without reading all rows
+	pr.CloseWithError(errLoadFinished)
+	if encodeErr := <-encoded; encodeErr != nil && !errors.Is(encodeErr, errLoadFinished) && err == nil {
+		err = fmt.Errorf("failed to encode rows: %w", encodeErr)
+	}
+	return resp, err
+}
+
+// encodeCSV writes rows as CSV without headers
+func encodeCSV[T any](ctx context.Context, w io.Writer, rows iter.Seq[T]) error {
+	values := make(chan interface{})
+	stop := make(chan struct{})
+	go func() {
+		defer close(values)
+		for row := range rows {
+			select {
+			case values <- row:
+			case <-stop:
+				return
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	err := gocsv.MarshalChanWithoutHeaders(values, gocsv.DefaultCSVWriter(w))
+	// Stop the producer if the writer failed
+	close(stop)
+	for range values {
+	}
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		// The rows were cut short, never let the load commit them
+		return ctxErr
+	}
+	if errors.Is(err, gocsv.ErrChannelIsClosed) {
+		// No rows
+		return nil
+	}
+	return err
+}
+
+// encodeJSONArray writes rows as a JSON array
+func encodeJSONArray[T any](w io.Writer, rows iter.Seq[T]) error {
+	bw := &errWriter{w: w}
+	bw.write([]byte("["))
+	first := true
+	for row := range rows {
+		data, err := json.Marshal(row)
+		if err != nil {
+			return fmt.Errorf("failed to marshal row: %w", err)
+		}
+		if !first {
+			bw.write([]byte(","))
+		}
+		first = false
+		bw.write(data)
+		if bw.err != nil {
+			return bw.err
+		}
+	}
+	bw.write([]byte("]"))
+	return bw.err
+}
+
+// errWriter remembers the first write error
+type errWriter struct {
+	w   io.Writer
+	err error
+}
+
+func (w *errWriter) write(p []byte) {
+	if w.err == nil {
+		_, w.err = w.w.Write(p)
+	}
+}
@@ -0,0 +1,90 @@
+package streamload
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+func TestLoadSlice_CSV(t *testing.T) {
+	var body, columns string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		body, columns = string(data), r.Header.Get("columns")
+		w.Write([]byte(`{"Status":"Success","NumberLoadedRows":2}`))
+	}))
+	defer server.Close()
+
+	client := newTestClient(t, server)
+	users := []TestUser{{Id: 1, Name: "Alice", Age: 25}, {Id: 2, Name: "Bob", Age: 30}}
+	if _, err := LoadSlice(client, "users", users, LoadOptions{Format: FormatCSV}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if body != "1,Alice,25\n2,Bob,30\n" {
+		t.Errorf("unexpected body %q", body)
+	}
+	if columns != "id,name,age" {
+		t.Errorf("unexpected columns %q", columns)
+	}
+}
+
+func TestLoadSeq_JSON(t *testing.T) {
+	var body string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		zr, err := zstd.NewReader(r.Body)
+		if err != nil {
+			t.Errorf("failed to create zstd reader: %v", err)
+			return
+		}
+		defer zr.Close()
+		data, _ := io.ReadAll(zr)
+		body = string(data)
+		w.Write([]byte(`{"Status":"Success"}`))
+	}))
+	defer server.Close()
+
+	client := newTestClient(t, server)
+	users := []*TestUser{{Id: 1, Name: "Alice", Age: 25}, {Id: 2, Name: "Bob", Age: 30}}
+	if _, err := LoadSeq(client, "users", slices.Values(users), LoadOptions{Streaming: true}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if body != `[{"id":1,"name":"Alice","age":25},{"id":2,"name":"Bob","age":30}]` {
+		t.Errorf("unexpected body %q", body)
+	}
+}
+
+func TestLoadSlice_RejectsNonStructs(t *testing.T) {
+	client := NewClient("127.0.0.1", "1", "test_db", "root", "")
+	if _, err := LoadSlice(client, "users", []int{1, 2}, LoadOptions{}); err == nil {
+		t.Error("expected an error for a non-struct element type")
+	}
+}
+
+func TestLoadSlice_OnlyUsesTagsOfFormat(t *testing.T) {
+	type jsonOnly struct {
+		Id   int    `json:"id" csv:"-"`
+		Name string `json:"name" csv:"-"`
+	}
+
+	var columns string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		columns = r.Header.Get("columns")
+		w.Write([]byte(`{"Status":"Success"}`))
+	}))
+	defer server.Close()
+
+	client := newTestClient(t, server)
+	if _, err := LoadSlice(client, "users", []jsonOnly{{Id: 1, Name: "Alice"}}, LoadOptions{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if columns != "id,name" {
+		t.Errorf("unexpected columns %q", columns)
+	}
+	if _, err := LoadSlice(client, "users", []jsonOnly{{Id: 1}}, LoadOptions{Format: FormatCSV}); err == nil {
+		t.Error("expected an error for a CSV load without csv columns")
+	}
+}
@@ -9,4 +9,4 @@ require (
 	github.com/pierrec/lz4/v4 v4.1.25
 )
 
-require github.com/gocarina/gocsv v0.0.0-20240520201108-78e41c74b4b1 // indirect
+require github.com/gocarina/gocsv v0.0.0-20240520201108-78e41c74b4b1
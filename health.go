@@ -0,0 +1,290 @@
+package streamload
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// CircuitState represents the state of an FE circuit breaker
+type CircuitState int
+
+const (
+	CircuitClosed   CircuitState = iota // Requests flow normally
+	CircuitOpen                         // The FE is skipped until OpenTimeout elapses
+	CircuitHalfOpen                     // A single trial request decides whether to close the circuit
+)
+
+// String returns the name of the circuit state
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitClosed:
+		return "closed"
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half-open"
+	default:
+		return fmt.Sprintf("CircuitState(%d)", int(s))
+	}
+}
+
+// HealthCheckConfig configures active FE health checking and circuit breaking
+type HealthCheckConfig struct {
+	// Interval between probes of every FE, defaults to 10s
+	Interval time.Duration
+	// Timeout of a single probe, defaults to 3s
+	Timeout time.Duration
+	// Path probed on each FE, defaults to /api/health
+	Path string
+	// FailureThreshold is the number of consecutive failures that opens the circuit, defaults to 3
+	FailureThreshold int
+	// OpenTimeout is how long an open circuit skips its FE before a half-open trial, defaults to 30s
+	OpenTimeout time.Duration
+}
+
+// EndpointStatus reports the health of a single FE endpoint
+type EndpointStatus struct {
+	Endpoint            FEEndpoint
+	Healthy             bool
+	Circuit             CircuitState
+	ConsecutiveFailures int
+	LastError           string
+	LastSuccess         time.Time
+	LastFailure         time.Time
+	Current             bool // Whether this FE is used for the next request
+}
+
+// endpointState tracks the health of a single FE, guarded by Client.mu
+type endpointState struct {
+	circuit             CircuitState
+	consecutiveFailures int
+	openedAt            time.Time
+	trialInFlight       bool
+	lastError           string
+	lastSuccess         time.Time
+	lastFailure         time.Time
+}
+
+// healthChecker runs the background FE probes
+type healthChecker struct {
+	config HealthCheckConfig
+	stop   chan struct{}
+	wg     sync.WaitGroup
+}
+
+// EnableHealthCheck starts probing every FE in the background and enables
+// circuit breaking, so that failing FEs are skipped when selecting an endpoint.
+// Call Close to stop the health checker.
+func (c *Client) EnableHealthCheck(config HealthCheckConfig) {
+	if config.Interval <= 0 {
+		config.Interval = 10 * time.Second
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = 3 * time.Second
+	}
+	if config.Path == "" {
+		config.Path = "/api/health"
+	}
+	if config.FailureThreshold <= 0 {
+		config.FailureThreshold = 3
+	}
+	if config.OpenTimeout <= 0 {
+		config.OpenTimeout = 30 * time.Second
+	}
+
+	c.stopHealthCheck()
+
+	checker := &healthChecker{config: config, stop: make(chan struct{})}
+	c.mu.Lock()
+	c.health = checker
+	c.mu.Unlock()
+
+	checker.wg.Add(1)
+	go func() {
+		defer checker.wg.Done()
+		ticker := time.NewTicker(config.Interval)
+		defer ticker.Stop()
+
+		c.probeAll(checker)
+		for {
+			select {
+			case <-checker.stop:
+				return
+			case <-ticker.C:
+				c.probeAll(checker)
+			}
+		}
+	}()
+}
+
+// EndpointStatus returns the health of every FE endpoint
+func (c *Client) EndpointStatus() []EndpointStatus {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	statuses := make([]EndpointStatus, len(c.fes))
+	for i, fe := range c.fes {
+		state := c.endpoints[i]
+		statuses[i] = EndpointStatus{
+			Endpoint:            fe,
+			Healthy:             state.circuit == CircuitClosed && state.consecutiveFailures == 0,
+			Circuit:             state.circuit,
+			ConsecutiveFailures: state.consecutiveFailures,
+			LastError:           state.lastError,
+			LastSuccess:         state.lastSuccess,
+			LastFailure:         state.lastFailure,
+			Current:             i == c.currentFEIndex,
+		}
+	}
+	return statuses
+}
+
+// Close stops background work started by the client, such as the health checker
+func (c *Client) Close() error {
+	c.stopHealthCheck()
+	return nil
+}
+
+// stopHealthCheck stops the running health checker, if any
+func (c *Client) stopHealthCheck() {
+	c.mu.Lock()
+	checker := c.health
+	c.health = nil
+	c.mu.Unlock()
+
+	if checker != nil {
+		close(checker.stop)
+		checker.wg.Wait()
+	}
+}
+
+// probeAll probes every FE concurrently
+func (c *Client) probeAll(checker *healthChecker) {
+	var wg sync.WaitGroup
+	for i := range c.fes {
+		wg.Add(1)
+		go func(idx int) {
+			defer wg.Done()
+			if err := c.probe(idx, checker.config); err != nil {
+				c.recordFailure(idx, err)
+			} else {
+				c.recordSuccess(idx)
+			}
+		}(i)
+	}
+	wg.Wait()
+}
+
+// probe sends a single health check request to the FE
+func (c *Client) probe(idx int, config HealthCheckConfig) error {
+	ctx, cancel := context.WithTimeout(context.Background(), config.Timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, "GET", c.feURL(idx)+config.Path, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("health check returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// selectFE returns the index of the FE for the next request, skipping FEs whose circuit is open
+func (c *Client) selectFE() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.health == nil {
+		return c.currentFEIndex
+	}
+
+	now := time.Now()
+	for i := 0; i < len(c.fes); i++ {
+		idx := (c.currentFEIndex + i) % len(c.fes)
+		if c.endpoints[idx].allow(now, c.health.config.OpenTimeout) {
+			c.currentFEIndex = idx
+			return idx
+		}
+	}
+
+	// Every circuit is open, fall back to round-robin rather than failing without trying
+	return c.currentFEIndex
+}
+
+// allow reports whether a request may be sent to the FE, moving an
+// expired open circuit to half-open and admitting a single trial request
+func (s *endpointState) allow(now time.Time, openTimeout time.Duration) bool {
+	switch s.circuit {
+	case CircuitOpen:
+		if now.Sub(s.openedAt) < openTimeout {
+			return false
+		}
+		s.circuit = CircuitHalfOpen
+		s.trialInFlight = true
+		return true
+	case CircuitHalfOpen:
+		if s.trialInFlight {
+			return false
+		}
+		s.trialInFlight = true
+		return true
+	default:
+		return true
+	}
+}
+
+// releaseTrial frees the half-open trial slot of the FE without recording an outcome
+func (c *Client) releaseTrial(idx int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.endpoints[idx].trialInFlight = false
+}
+
+// recordSuccess closes the circuit of the FE
+func (c *Client) recordSuccess(idx int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	state := c.endpoints[idx]
+	state.circuit = CircuitClosed
+	state.consecutiveFailures = 0
+	state.trialInFlight = false
+	state.lastSuccess = time.Now()
+}
+
+// recordFailure counts a failure of the FE and opens its circuit once the threshold is reached
+func (c *Client) recordFailure(idx int, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	state := c.endpoints[idx]
+	state.consecutiveFailures++
+	state.trialInFlight = false
+	state.lastError = err.Error()
+	state.lastFailure = time.Now()
+
+	if c.health == nil {
+		return
+	}
+	if state.circuit == CircuitHalfOpen || state.consecutiveFailures >= c.health.config.FailureThreshold {
+		if state.circuit != CircuitOpen && c.logger != nil {
+			fe := c.fes[idx]
+			c.logger.Printf("[DEBUG] Circuit opened for FE %s:%s after %d failures: %v", fe.Host, fe.Port, state.consecutiveFailures, err)
+		}
+		state.circuit = CircuitOpen
+		state.openedAt = state.lastFailure
+	}
+}
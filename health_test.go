@@ -0,0 +1,85 @@
+package streamload
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEndpointState_HalfOpen(t *testing.T) {
+	now := time.Now()
+	state := &endpointState{circuit: CircuitOpen, openedAt: now}
+
+	if state.allow(now.Add(time.Second), time.Minute) {
+		t.Fatal("open circuit should reject requests before the open timeout")
+	}
+	if !state.allow(now.Add(2*time.Minute), time.Minute) {
+		t.Fatal("expired open circuit should admit a trial request")
+	}
+	if state.circuit != CircuitHalfOpen {
+		t.Fatalf("expected half-open circuit, got %s", state.circuit)
+	}
+	if state.allow(now.Add(2*time.Minute), time.Minute) {
+		t.Fatal("half-open circuit should admit a single trial request")
+	}
+}
+
+func TestHealthCheck_SkipsDeadFE(t *testing.T) {
+	// Reserve a port and close it so that connections are refused
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	deadHost, deadPort, _ := net.SplitHostPort(listener.Addr().String())
+	listener.Close()
+
+	loads := 0
+	live := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/health" {
+			w.Write([]byte(`{"status":"OK"}`))
+			return
+		}
+		loads++
+		w.Write([]byte(`{"Status":"Success"}`))
+	}))
+	defer live.Close()
+	liveHost, livePort, _ := net.SplitHostPort(strings.TrimPrefix(live.URL, "http://"))
+
+	client := NewClientWithFEs([]FEEndpoint{
+		{Host: deadHost, Port: deadPort},
+		{Host: liveHost, Port: livePort},
+	}, "test_db", "root", "")
+	defer client.Close()
+
+	client.EnableHealthCheck(HealthCheckConfig{
+		Interval:         10 * time.Millisecond,
+		FailureThreshold: 2,
+		OpenTimeout:      time.Hour,
+	})
+
+	deadline := time.Now().Add(5 * time.Second)
+	for client.EndpointStatus()[0].Circuit != CircuitOpen {
+		if time.Now().After(deadline) {
+			t.Fatalf("circuit of the dead FE was not opened: %+v", client.EndpointStatus())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if _, err := client.Load("users", strings.NewReader("1,a"), LoadOptions{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if loads != 1 {
+		t.Errorf("expected the load to reach the live FE, got %d loads", loads)
+	}
+
+	statuses := client.EndpointStatus()
+	if statuses[0].Healthy || statuses[0].LastError == "" {
+		t.Errorf("dead FE should be reported unhealthy: %+v", statuses[0])
+	}
+	if !statuses[1].Healthy || !statuses[1].Current {
+		t.Errorf("live FE should be healthy and current: %+v", statuses[1])
+	}
+}
@@ -0,0 +1,285 @@
+package streamload
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// JournalPhase is the last phase of a transaction recorded in a TransactionJournal
+type JournalPhase string
+
+const (
+	JournalBegun    JournalPhase = "BEGUN"    // Begin was sent, the transaction may be loading
+	JournalPrepared JournalPhase = "PREPARED" // Prepare succeeded, the transaction waits for commit
+)
+
+// RecoveryPolicy decides what RecoverTransactions does with the transactions it finds
+type RecoveryPolicy int
+
+const (
+	// RecoverCommitPrepared commits prepared transactions and rolls back the others
+	RecoverCommitPrepared RecoveryPolicy = iota
+	// RecoverRollbackAll rolls back every transaction, prepared or not
+	RecoverRollbackAll
+	// RecoverReportOnly looks up the transactions without changing them
+	RecoverReportOnly
+)
+
+// RecoveryAction is what RecoverTransactions did with a transaction
+type RecoveryAction string
+
+const (
+	RecoveryNone       RecoveryAction = "NONE"        // Nothing was left to do, or the policy is RecoverReportOnly
+	RecoveryCommitted  RecoveryAction = "COMMITTED"   // The prepared transaction was committed
+	RecoveryRolledBack RecoveryAction = "ROLLED_BACK" // The transaction was rolled back
+)
+
+// TransactionJournal records the labels and phases of the in-flight transactions of
+// a client in a local directory, so the transactions of a crashed process can be
+// finished with RecoverTransactions. Every transaction is a file <label>.json with
+// the label escaped for use as a file name, holding a JournalEntry. Entries are
+// written before a transaction is begun and removed once it is committed or rolled
+// back. Enable it with Client.SetTransactionJournal.
+type TransactionJournal struct {
+	path string
+	mu   sync.Mutex
+}
+
+// JournalEntry is a transaction recorded in a TransactionJournal
+type JournalEntry struct {
+	Label     string       `json:"label"`
+	Tables    []string     `json:"tables"`
+	Phase     JournalPhase `json:"phase"`
+	TxnId     int64        `json:"txn_id,omitempty"`
+	UpdatedAt time.Time    `json:"updated_at"`
+}
+
+// RecoveryResult reports what RecoverTransactions did with a journaled transaction
+type RecoveryResult struct {
+	Entry  JournalEntry
+	State  LoadState // State of the label on the server
+	Action RecoveryAction
+	Err    error
+}
+
+// OpenTransactionJournal opens the transaction journal at path, creating the directory if needed
+func OpenTransactionJournal(path string) (*TransactionJournal, error) {
+	if err := os.MkdirAll(path, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create transaction journal: %w", err)
+	}
+	return &TransactionJournal{path: path}, nil
+}
+
+// SetTransactionJournal records the transactions begun by this client in journal. Pass nil to disable it.
+func (c *Client) SetTransactionJournal(journal *TransactionJournal) {
+	c.journal = journal
+}
+
+// Path returns the directory of the journal
+func (j *TransactionJournal) Path() string {
+	return j.path
+}
+
+// fileName returns the file name of the entry of label
+func (j *TransactionJournal) fileName(label string) string {
+	return url.PathEscape(label) + ".json"
+}
+
+// record writes the entry of a transaction
+func (j *TransactionJournal) record(entry JournalEntry) error {
+	entry.UpdatedAt = time.Now().UTC()
+	data, err := json.MarshalIndent(entry, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode journal entry: %w", err)
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if err := writeFileAtomic(j.path, j.fileName(entry.Label), data); err != nil {
+		return fmt.Errorf("failed to write journal entry: %w", err)
+	}
+	return nil
+}
+
+// advance updates the phase of a recorded transaction
+func (j *TransactionJournal) advance(label string, phase JournalPhase, txnID int64) error {
+	j.mu.Lock()
+	data, err := os.ReadFile(filepath.Join(j.path, j.fileName(label)))
+	j.mu.Unlock()
+	if err != nil {
+		return fmt.Errorf("failed to read journal entry: %w", err)
+	}
+
+	var entry JournalEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return fmt.Errorf("failed to parse journal entry: %w", err)
+	}
+	entry.Phase = phase
+	if txnID != 0 {
+		entry.TxnId = txnID
+	}
+	return j.record(entry)
+}
+
+// remove deletes the entry of a finished transaction
+func (j *TransactionJournal) remove(label string) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	err := os.Remove(filepath.Join(j.path, j.fileName(label)))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// Entries returns the recorded transactions, least recently updated first
+func (j *TransactionJournal) Entries() ([]JournalEntry, error) {
+	names, err := filepath.Glob(filepath.Join(j.path, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]JournalEntry, 0, len(names))
+	for _, name := range names {
+		data, err := os.ReadFile(name)
+		if errors.Is(err, os.ErrNotExist) {
+			// Finished in the meantime
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read journal entry: %w", err)
+		}
+		var entry JournalEntry
+		if err := json.Unmarshal(data, &entry); err != nil {
+			return nil, fmt.Errorf("failed to parse journal entry %s: %w", name, err)
+		}
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(a, b int) bool {
+		return entries[a].UpdatedAt.Before(entries[b].UpdatedAt)
+	})
+	return entries, nil
+}
+
+// journalPhase advances the journal entry of a transaction, a failure is only logged
+// because RecoverTransactions relies on the label state rather than the phase
+func (c *Client) journalPhase(label string, phase JournalPhase, txnID int64) {
+	if c.journal == nil {
+		return
+	}
+	if err := c.journal.advance(label, phase, txnID); err != nil && c.logger != nil {
+		c.logger.Printf("[DEBUG] Failed to journal phase %s of transaction %s: %v", phase, label, err)
+	}
+}
+
+// journalDone removes the journal entry of a finished transaction
+func (c *Client) journalDone(label string) {
+	if c.journal == nil {
+		return
+	}
+	if err := c.journal.remove(label); err != nil && c.logger != nil {
+		c.logger.Printf("[DEBUG] Failed to remove journal entry of transaction %s: %v", label, err)
+	}
+}
+
+// RecoverTransactions finishes the transactions left in journal by a previous process.
+// The label state of every entry decides what happens: PREPARED transactions are
+// committed or rolled back according to policy, transactions still loading (PREPARE)
+// are rolled back, and entries of transactions that are already finished or were
+// never begun are removed. An entry is only removed after a successful commit or
+// rollback, or once the label state is VISIBLE, COMMITTED or ABORTED; if a commit or
+// rollback fails, the label state is looked up again to tell whether it took effect.
+// Transactions in progress in this client are skipped.
+//
+// Failures are reported in the result of their entry, which is kept unless the
+// transaction turned out to be finished; the error is only set if the journal cannot
+// be read or ctx is done.
+func (c *Client) RecoverTransactions(ctx context.Context, journal *TransactionJournal, policy RecoveryPolicy) ([]RecoveryResult, error) {
+	entries, err := journal.Entries()
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]RecoveryResult, 0, len(entries))
+	for _, entry := range entries {
+		if err := ctx.Err(); err != nil {
+			return results, err
+		}
+		c.txnsMu.Lock()
+		_, live := c.txns[entry.Label]
+		c.txnsMu.Unlock()
+		if live {
+			continue
+		}
+
+		result := c.recoverTransaction(ctx, journal, entry, policy)
+		if c.logger != nil {
+			c.logger.Printf("[DEBUG] Recovered transaction %s in state %s: %s, %v", entry.Label, result.State, result.Action, result.Err)
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// recoverTransaction finishes a single journaled transaction
+func (c *Client) recoverTransaction(ctx context.Context, journal *TransactionJournal, entry JournalEntry, policy RecoveryPolicy) RecoveryResult {
+	result := RecoveryResult{Entry: entry, Action: RecoveryNone}
+	result.State, result.Err = c.GetLoadStateContext(ctx, entry.Label)
+	if result.Err != nil {
+		result.Err = fmt.Errorf("failed to look up transaction %s: %w", entry.Label, result.Err)
+		return result
+	}
+	if policy == RecoverReportOnly {
+		return result
+	}
+
+	switch result.State {
+	case LoadStatePrepared:
+		if policy == RecoverCommitPrepared {
+			_, result.Err = c.CommitTransactionContext(ctx, entry.Label)
+			result.Action = RecoveryCommitted
+		} else {
+			_, result.Err = c.RollbackTransactionContext(ctx, entry.Label)
+			result.Action = RecoveryRolledBack
+		}
+	case LoadStatePrepare:
+		_, result.Err = c.RollbackTransactionContext(ctx, entry.Label)
+		result.Action = RecoveryRolledBack
+	case LoadStateVisible, LoadStateCommitted, LoadStateAborted, LoadStateUnknown:
+		// Finished or never begun, only the removal of the entry was lost
+	default:
+		result.Err = fmt.Errorf("transaction %s is still in state %s", entry.Label, result.State)
+		return result
+	}
+
+	if result.Err != nil {
+		// The action may have taken effect even though its response was lost or
+		// rejected, so only the label state decides whether the entry can go
+		action := result.Action
+		result.Action = RecoveryNone
+		state, err := c.GetLoadStateContext(ctx, entry.Label)
+		if err != nil {
+			return result
+		}
+		result.State = state
+		switch {
+		case action == RecoveryCommitted && (state == LoadStateVisible || state == LoadStateCommitted),
+			action == RecoveryRolledBack && state == LoadStateAborted:
+			result.Action, result.Err = action, nil
+		case state != LoadStateVisible && state != LoadStateCommitted && state != LoadStateAborted:
+			return result
+		}
+	}
+	if err := journal.remove(entry.Label); err != nil {
+		result.Err = errors.Join(result.Err, fmt.Errorf("failed to remove journal entry: %w", err))
+	}
+	return result
+}
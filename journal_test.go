@@ -0,0 +1,186 @@
+package streamload
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestTransactionJournal(t *testing.T) {
+	var mu sync.Mutex
+	var calls []string
+	server := transactionServer(&calls, &mu)
+	defer server.Close()
+
+	journal, err := OpenTransactionJournal(t.TempDir())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	client := newTestClient(t, server)
+	client.SetTransactionJournal(journal)
+
+	ctx := context.Background()
+	tx, err := client.Begin(ctx, "txn:1", []string{"orders", "customers"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	entries, _ := journal.Entries()
+	if len(entries) != 1 || entries[0].Label != "txn:1" || entries[0].Phase != JournalBegun ||
+		entries[0].TxnId != 7 || len(entries[0].Tables) != 2 {
+		t.Fatalf("unexpected entries %+v", entries)
+	}
+
+	if _, err := tx.Prepare(ctx); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if entries, _ := journal.Entries(); len(entries) != 1 || entries[0].Phase != JournalPrepared {
+		t.Fatalf("unexpected entries %+v", entries)
+	}
+
+	if _, err := tx.Commit(ctx); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if entries, _ := journal.Entries(); len(entries) != 0 {
+		t.Errorf("expected the entry to be removed, got %+v", entries)
+	}
+
+	rejected, err := client.Begin(ctx, "rejected", []string{"orders"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := rejected.Commit(ctx); err == nil {
+		t.Fatal("expected the commit to be rejected")
+	}
+	if entries, _ := journal.Entries(); len(entries) != 1 || entries[0].Phase != JournalPrepared {
+		t.Errorf("expected the entry of the rejected commit to be kept, got %+v", entries)
+	}
+}
+
+func TestRecoverTransactions(t *testing.T) {
+	states := map[string]string{"prepared": "PREPARED", "loading": "PREPARE", "gone": "UNKNOWN"}
+	tests := []struct {
+		name    string
+		policy  RecoveryPolicy
+		want    map[string]RecoveryAction
+		calls   []string
+		entries int
+	}{
+		{
+			name:   "commit prepared",
+			policy: RecoverCommitPrepared,
+			want:   map[string]RecoveryAction{"prepared": RecoveryCommitted, "loading": RecoveryRolledBack, "gone": RecoveryNone},
+			calls:  []string{"commit prepared", "rollback loading"},
+		},
+		{
+			name:   "rollback all",
+			policy: RecoverRollbackAll,
+			want:   map[string]RecoveryAction{"prepared": RecoveryRolledBack, "loading": RecoveryRolledBack, "gone": RecoveryNone},
+			calls:  []string{"rollback prepared", "rollback loading"},
+		},
+		{
+			name:    "report only",
+			policy:  RecoverReportOnly,
+			want:    map[string]RecoveryAction{"prepared": RecoveryNone, "loading": RecoveryNone, "gone": RecoveryNone},
+			entries: 3,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var mu sync.Mutex
+			var calls []string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path == "/api/test_db/get_load_state" {
+					w.Write([]byte(`{"state":"` + states[r.URL.Query().Get("label")] + `","status":"OK"}`))
+					return
+				}
+				mu.Lock()
+				calls = append(calls, strings.TrimPrefix(r.URL.Path, "/api/transaction/")+" "+r.Header.Get("label"))
+				mu.Unlock()
+				w.Write([]byte(`{"Status":"OK"}`))
+			}))
+			defer server.Close()
+
+			journal, _ := OpenTransactionJournal(t.TempDir())
+			for _, label := range []string{"prepared", "loading", "gone"} {
+				journal.record(JournalEntry{Label: label, Tables: []string{"orders"}, Phase: JournalBegun})
+			}
+
+			client := newTestClient(t, server)
+			results, err := client.RecoverTransactions(context.Background(), journal, tt.policy)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(results) != 3 {
+				t.Fatalf("expected 3 results, got %+v", results)
+			}
+			for _, result := range results {
+				if result.Err != nil || result.Action != tt.want[result.Entry.Label] {
+					t.Errorf("unexpected result %+v", result)
+				}
+			}
+			if strings.Join(calls, ",") != strings.Join(tt.calls, ",") {
+				t.Errorf("expected calls %v, got %v", tt.calls, calls)
+			}
+			if entries, _ := journal.Entries(); len(entries) != tt.entries {
+				t.Errorf("expected %d entries left, got %+v", tt.entries, entries)
+			}
+		})
+	}
+}
+
+func TestRecoverTransactions_RejectedAction(t *testing.T) {
+	tests := []struct {
+		name    string
+		after   string // Label state after the rejected commit
+		action  RecoveryAction
+		entries int
+	}{
+		{name: "still prepared", after: "PREPARED", action: RecoveryNone, entries: 1},
+		{name: "committed anyway", after: "VISIBLE", action: RecoveryCommitted, entries: 0},
+		{name: "aborted", after: "ABORTED", action: RecoveryNone, entries: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var mu sync.Mutex
+			state := "PREPARED"
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				mu.Lock()
+				defer mu.Unlock()
+				if r.URL.Path == "/api/test_db/get_load_state" {
+					w.Write([]byte(`{"state":"` + state + `","status":"OK"}`))
+					return
+				}
+				state = tt.after
+				w.Write([]byte(`{"Status":"FAILED","Message":"publish failed"}`))
+			}))
+			defer server.Close()
+
+			journal, _ := OpenTransactionJournal(t.TempDir())
+			journal.record(JournalEntry{Label: "prepared", Tables: []string{"orders"}, Phase: JournalPrepared})
+
+			client := newTestClient(t, server)
+			results, err := client.RecoverTransactions(context.Background(), journal, RecoverCommitPrepared)
+			if err != nil || len(results) != 1 {
+				t.Fatalf("unexpected results %+v, %v", results, err)
+			}
+			result := results[0]
+			if result.Action != tt.action {
+				t.Errorf("expected action %s, got %s", tt.action, result.Action)
+			}
+			if (result.Err == nil) != (tt.action == RecoveryCommitted) {
+				t.Errorf("unexpected error %v", result.Err)
+			}
+			if string(result.State) != tt.after {
+				t.Errorf("expected state %s, got %s", tt.after, result.State)
+			}
+			if entries, _ := journal.Entries(); len(entries) != tt.entries {
+				t.Errorf("expected %d entries left, got %+v", tt.entries, entries)
+			}
+		})
+	}
+}
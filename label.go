@@ -0,0 +1,138 @@
+package streamload
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// LoadState is the state of a load job or transaction identified by its label
+type LoadState string
+
+const (
+	LoadStateUnknown   LoadState = "UNKNOWN"   // No job with this label exists
+	LoadStatePrepare   LoadState = "PREPARE"   // The job is running
+	LoadStatePrepared  LoadState = "PREPARED"  // The transaction is prepared and waits for commit
+	LoadStateCommitted LoadState = "COMMITTED" // The data is committed and will become visible
+	LoadStateVisible   LoadState = "VISIBLE"   // The data is visible
+	LoadStateAborted   LoadState = "ABORTED"   // The job failed or was rolled back, the label may be reused
+)
+
+// loadOnceAttempts is the number of submissions made by LoadOnce
+const loadOnceAttempts = 3
+
+// LoadStateResponse represents the response of the label state endpoint
+type LoadStateResponse struct {
+	Status  string    `json:"status"`
+	Message string    `json:"msg"`
+	State   LoadState `json:"state"`
+	// Data holds the state in older FE versions, GetLoadState copies it into State
+	Data LoadState `json:"data"`
+}
+
+func (r *LoadStateResponse) info() responseInfo {
+	return responseInfo{Status: r.Status, Message: r.Message}
+}
+
+// GetLoadState returns the state of the load job or transaction with the given label
+func (c *Client) GetLoadState(label string) (LoadState, error) {
+	return c.GetLoadStateContext(context.Background(), label)
+}
+
+// GetLoadStateContext is like GetLoadState but honors ctx for cancellation
+func (c *Client) GetLoadStateContext(ctx context.Context, label string) (LoadState, error) {
+	headers := map[string]string{"Content-Type": "application/json"}
+	path := fmt.Sprintf("/api/%s/get_load_state?label=%s", c.database, url.QueryEscape(label))
+
+	resp, err := call[LoadStateResponse](c, ctx, "get load state", "GET", path, headers, nil, "OK")
+	if err != nil {
+		return "", err
+	}
+	if resp.State == "" {
+		resp.State = resp.Data
+	}
+	if resp.State == "" {
+		return "", fmt.Errorf("get load state returned no state for label %s", label)
+	}
+	return resp.State, nil
+}
+
+// LoadOnce loads data exactly once under its label. When a load fails in a way
+// that leaves its outcome unknown (a transport error, a 5xx response, "Publish
+// Timeout" or "Label Already Exists"), the label state decides what happens next:
+// VISIBLE and COMMITTED are reported as success, UNKNOWN and ABORTED resubmit
+// the data, and a job still in progress is returned as an error.
+//
+// A label is generated when opts.Label is empty. The data is buffered so it can
+// be resubmitted, opts.Streaming is ignored. When success is confirmed through
+// the label state, the returned response may lack load statistics.
+func (c *Client) LoadOnce(ctx context.Context, table string, data io.Reader, opts LoadOptions) (*LoadResponse, error) {
+	if opts.Label == "" {
+		opts.Label = newLabel()
+	}
+	opts.Streaming = false
+
+	body, err := c.newPayload(ctx, data, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer body.close()
+
+	for attempt := 1; ; attempt++ {
+		resp, err := c.load(ctx, table, body, opts)
+		if err == nil || !isAmbiguousLoadError(err) {
+			return resp, err
+		}
+
+		state, stateErr := c.GetLoadStateContext(ctx, opts.Label)
+		if stateErr != nil {
+			return resp, fmt.Errorf("load with label %s failed and its state is unknown: %w (state lookup: %v)", opts.Label, err, stateErr)
+		}
+		if c.logger != nil {
+			c.logger.Printf("[DEBUG] LoadOnce: label %s is %s after error: %v", opts.Label, state, err)
+		}
+
+		switch state {
+		case LoadStateVisible, LoadStateCommitted:
+			if resp == nil {
+				resp = &LoadResponse{Label: opts.Label}
+			}
+			resp.Status = "Success"
+			resp.Message = fmt.Sprintf("load confirmed by label state %s", state)
+			return resp, nil
+		case LoadStateUnknown, LoadStateAborted:
+			if attempt >= loadOnceAttempts {
+				return resp, fmt.Errorf("load with label %s was not applied after %d attempts: %w", opts.Label, attempt, err)
+			}
+		default:
+			return resp, fmt.Errorf("load with label %s is still in state %s: %w", opts.Label, state, err)
+		}
+
+		delay := c.retryPolicy.backoff(attempt)
+		select {
+		case <-ctx.Done():
+			return resp, fmt.Errorf("load with label %s aborted: %w (last error: %v)", opts.Label, ctx.Err(), err)
+		case <-time.After(delay):
+		}
+	}
+}
+
+// isAmbiguousLoadError reports whether a failed load may nevertheless have been applied
+func isAmbiguousLoadError(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	var loadErr *LoadError
+	if !errors.As(err, &loadErr) {
+		// Transport errors, the response may have been lost after the load completed
+		return true
+	}
+	return loadErr.StatusCode >= http.StatusInternalServerError ||
+		errors.Is(loadErr, ErrPublishTimeout) ||
+		errors.Is(loadErr, ErrLabelAlreadyExists)
+}
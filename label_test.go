@@ -0,0 +1,103 @@
+package streamload
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestGetLoadState(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/test_db/get_load_state" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		switch r.URL.Query().Get("label") {
+		case "old-fe":
+			w.Write([]byte(`{"msg":"success","code":0,"data":"VISIBLE","status":"OK"}`))
+		default:
+			w.Write([]byte(`{"state":"PREPARED","status":"OK"}`))
+		}
+	}))
+	defer server.Close()
+
+	client := newTestClient(t, server)
+	for label, want := range map[string]LoadState{"old-fe": LoadStateVisible, "new-fe": LoadStatePrepared} {
+		state, err := client.GetLoadState(label)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if state != want {
+			t.Errorf("%s: expected %s, got %s", label, want, state)
+		}
+	}
+}
+
+// loadOnceServer fails the first load with a 500 and answers label lookups with state
+func loadOnceServer(t *testing.T, state LoadState, loads *int) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/get_load_state") {
+			w.Write([]byte(`{"state":"` + string(state) + `","status":"OK"}`))
+			return
+		}
+		*loads++
+		if *loads == 1 {
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write([]byte(`{"Status":"Fail","Message":"connection reset"}`))
+			return
+		}
+		w.Write([]byte(`{"Status":"Success","NumberLoadedRows":1}`))
+	}))
+}
+
+func TestLoadOnce_ConfirmedByLabelState(t *testing.T) {
+	loads := 0
+	server := loadOnceServer(t, LoadStateVisible, &loads)
+	defer server.Close()
+
+	client := newTestClient(t, server)
+	resp, err := client.LoadOnce(context.Background(), "users", strings.NewReader("1,a"), LoadOptions{Label: "once-1"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if loads != 1 {
+		t.Errorf("a visible label must not be loaded again, got %d loads", loads)
+	}
+	if resp.Status != "Success" {
+		t.Errorf("expected Success, got %s", resp.Status)
+	}
+}
+
+func TestLoadOnce_ResubmitsUnknownLabel(t *testing.T) {
+	loads := 0
+	server := loadOnceServer(t, LoadStateUnknown, &loads)
+	defer server.Close()
+
+	client := newTestClient(t, server)
+	resp, err := client.LoadOnce(context.Background(), "users", strings.NewReader("1,a"), LoadOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if loads != 2 {
+		t.Errorf("expected the data to be resubmitted once, got %d loads", loads)
+	}
+	if resp.NumberLoadedRows != 1 {
+		t.Errorf("expected 1 loaded row, got %d", resp.NumberLoadedRows)
+	}
+}
+
+func TestLoadOnce_InProgressIsAnError(t *testing.T) {
+	loads := 0
+	server := loadOnceServer(t, LoadStatePrepare, &loads)
+	defer server.Close()
+
+	client := newTestClient(t, server)
+	_, err := client.LoadOnce(context.Background(), "users", strings.NewReader("1,a"), LoadOptions{Label: "once-2"})
+	if err == nil || !strings.Contains(err.Error(), "PREPARE") {
+		t.Fatalf("expected an in-progress error, got %v", err)
+	}
+	if loads != 1 {
+		t.Errorf("expected no resubmission, got %d loads", loads)
+	}
+}
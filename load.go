@@ -1,18 +1,63 @@
 package streamload
 
 import (
-	"bytes"
-	"encoding/json"
+	"context"
 	"fmt"
 	"io"
-	"net/http"
 	"strings"
+
+	"github.com/google/uuid"
 )
 
 // Load loads data into StarRocks via stream load
 func (c *Client) Load(table string, data io.Reader, opts LoadOptions) (*LoadResponse, error) {
-	urlStr := fmt.Sprintf("%s/api/%s/%s/_stream_load", c.getCurrentFEURL(), c.database, table)
+	return c.LoadContext(context.Background(), table, data, opts)
+}
+
+// LoadContext loads data into StarRocks via stream load.
+// Cancelling ctx aborts compression, the FE request and the redirected BE request.
+func (c *Client) LoadContext(ctx context.Context, table string, data io.Reader, opts LoadOptions) (*LoadResponse, error) {
+	// Retries must reuse the same label, otherwise a retried load may be applied twice
+	if opts.Label == "" && c.retryPolicy.attempts() > 1 {
+		opts.Label = newLabel()
+	}
+
+	body, err := c.newPayload(ctx, data, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer body.close()
+
+	return c.load(ctx, table, body, opts)
+}
+
+// load sends a prepared payload via stream load
+func (c *Client) load(ctx context.Context, table string, body payload, opts LoadOptions) (*LoadResponse, error) {
+	headers := c.loadHeaders(opts)
+	path := fmt.Sprintf("/api/%s/%s/_stream_load", c.database, table)
+	resp, err := call[LoadResponse](c, ctx, "stream load", "PUT", path, headers, body, "Success")
+	c.attachRejectedRows(ctx, resp, err, opts)
+	return resp, err
+}
+
+// newPayload prepares the request body of a load. Uncompressed seekable inputs are
+// sent directly, other inputs are streamed or buffered to support resending them
+// after a redirect or a retry.
+func (c *Client) newPayload(ctx context.Context, data io.Reader, opts LoadOptions) (payload, error) {
+	if opts.Compression == CompressionNone {
+		if body, ok := newSeekablePayload(data); ok {
+			return body, nil
+		}
+	}
+	if opts.Streaming {
+		return newStreamPayload(ctx, data, opts.Compression), nil
+	}
+
+	return c.bufferData(ctx, data, opts.Compression)
+}
 
+// loadHeaders builds the stream load headers shared by Load and LoadTransaction
+func (c *Client) loadHeaders(opts LoadOptions) map[string]string {
 	headers := make(map[string]string)
 	for k, v := range c.defaultHeader {
 		headers[k] = v
@@ -51,26 +96,6 @@ func (c *Client) Load(table string, data io.Reader, opts LoadOptions) (*LoadResp
 		headers["compression"] = string(opts.Compression)
 	}
 
-	// Compress data into buffer to support retry on redirect
-	var dataBuf bytes.Buffer
-	var reader io.Reader = data
-	var err error
-	if opts.Compression != CompressionNone {
-		compressedReader, err := c.compressData(data, opts.Compression)
-		if err != nil {
-			return nil, fmt.Errorf("failed to compress data: %w", err)
-		}
-		if _, err := io.Copy(&dataBuf, compressedReader); err != nil {
-			return nil, fmt.Errorf("failed to buffer compressed data: %w", err)
-		}
-		reader = &dataBuf
-	} else {
-		if _, err := io.Copy(&dataBuf, data); err != nil {
-			return nil, fmt.Errorf("failed to buffer data: %w", err)
-		}
-		reader = &dataBuf
-	}
-
 	if opts.Label != "" {
 		headers["label"] = opts.Label
 	}
@@ -90,65 +115,33 @@ func (c *Client) Load(table string, data io.Reader, opts LoadOptions) (*LoadResp
 		headers["load_mem_limit"] = fmt.Sprintf("%d", opts.LoadMemLimit)
 	}
 
-	req, err := http.NewRequest("PUT", urlStr, reader)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
-	}
-
-	req.SetBasicAuth(c.username, c.password)
-	for k, v := range headers {
-		req.Header.Set(k, v)
-	}
-
-	resp, err := c.doRequest(req)
-	if err != nil {
-		return nil, fmt.Errorf("failed to send request: %w", err)
-	}
-
-	// Handle 307 Temporary Redirect from FE to BE
-	if resp.StatusCode == http.StatusTemporaryRedirect {
-		location := resp.Header.Get("Location")
-		if location == "" {
-			resp.Body.Close()
-			return nil, fmt.Errorf("received 307 redirect without Location header")
-		}
-
-		resp.Body.Close()
-
-		redirectReq, err := http.NewRequest("PUT", location, &dataBuf)
-		if err != nil {
-			return nil, fmt.Errorf("failed to create redirect request: %w", err)
-		}
+	return headers
+}
 
-		redirectReq.SetBasicAuth(c.username, c.password)
-		for k, v := range headers {
-			redirectReq.Header.Set(k, v)
+// bufferData compresses data into memory, or into a spool file once it exceeds
+// the spool threshold, so it can be resent after a redirect or a retry
+func (c *Client) bufferData(ctx context.Context, data io.Reader, compression CompressionType) (payload, error) {
+	buf := &spoolBuffer{threshold: c.spool.Threshold, dir: c.spool.Dir}
+	data = newContextReader(ctx, data)
+	if compression != CompressionNone {
+		if err := c.compressTo(buf, data, compression); err != nil {
+			buf.discard()
+			return nil, fmt.Errorf("failed to compress data: %w", err)
 		}
-
-		resp, err = c.httpClient.Do(redirectReq)
-		if err != nil {
-			return nil, fmt.Errorf("failed to send redirect request: %w", err)
+	} else {
+		if _, err := io.Copy(buf, data); err != nil {
+			buf.discard()
+			return nil, fmt.Errorf("failed to buffer data: %w", err)
 		}
 	}
-	defer resp.Body.Close()
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read response body: %w", err)
-	}
-
-	var loadResp LoadResponse
-	if err := json.Unmarshal(body, &loadResp); err != nil {
-		return nil, fmt.Errorf("failed to parse response: %w", err)
-	}
 
-	if resp.StatusCode != http.StatusOK {
-		return &loadResp, fmt.Errorf("stream load failed with status %d: %s", resp.StatusCode, loadResp.Message)
-	}
-
-	if loadResp.Status != "Success" {
-		return &loadResp, fmt.Errorf("stream load failed: %s", loadResp.Message)
+	if buf.file != nil && c.logger != nil {
+		c.logger.Printf("[DEBUG] Spooled %d bytes to %s", buf.n, buf.file.Name())
 	}
+	return buf.payload(), nil
+}
 
-	return &loadResp, nil
+// newLabel generates a unique load label
+func newLabel() string {
+	return "streamload-" + uuid.NewString()
 }
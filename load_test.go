@@ -0,0 +1,82 @@
+package streamload
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newTestClient creates a client pointing at the given test server
+func newTestClient(t *testing.T, server *httptest.Server) *Client {
+	t.Helper()
+	host, port, err := net.SplitHostPort(strings.TrimPrefix(server.URL, "http://"))
+	if err != nil {
+		t.Fatalf("failed to parse test server URL: %v", err)
+	}
+	return NewClient(host, port, "test_db", "root", "")
+}
+
+func TestLoadContext_Redirect(t *testing.T) {
+	be := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"Status":"Success","NumberLoadedRows":2}`))
+	}))
+	defer be.Close()
+
+	fe := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, be.URL+r.URL.Path, http.StatusTemporaryRedirect)
+	}))
+	defer fe.Close()
+
+	client := newTestClient(t, fe)
+	resp, err := client.LoadContext(context.Background(), "users", strings.NewReader("1,a\n2,b"), LoadOptions{Format: FormatCSV})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.NumberLoadedRows != 2 {
+		t.Errorf("expected 2 loaded rows, got %d", resp.NumberLoadedRows)
+	}
+}
+
+func TestLoadContext_Cancel(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-release:
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+
+	client := newTestClient(t, server)
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := client.LoadContext(ctx, "users", strings.NewReader("1,a"), LoadOptions{})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("load was not cancelled promptly, took %s", elapsed)
+	}
+}
+
+func TestLoadContext_CancelledBeforeCompression(t *testing.T) {
+	client := NewClient("127.0.0.1", "1", "test_db", "root", "")
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := client.LoadContext(ctx, "users", strings.NewReader(`{"id":1}`), LoadOptions{
+		Format:      FormatJSON,
+		Compression: CompressionGZIP,
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+}
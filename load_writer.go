@@ -0,0 +1,83 @@
+package streamload
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+)
+
+// errLoadFinished unblocks writers when the load ended before Close
+var errLoadFinished = errors.New("stream load already finished")
+
+// LoadWriter streams everything written to it into a single stream load request.
+// It implements io.WriteCloser, so encoders such as csv.Writer or json.Encoder can
+// write to StarRocks directly. Close ends the request and waits for its response.
+type LoadWriter struct {
+	pw   *io.PipeWriter
+	done chan struct{}
+
+	once sync.Once
+	resp *LoadResponse
+	err  error
+}
+
+// OpenLoad starts a stream load into table whose body is written through the returned LoadWriter
+func (c *Client) OpenLoad(table string, opts LoadOptions) *LoadWriter {
+	return c.OpenLoadContext(context.Background(), table, opts)
+}
+
+// OpenLoadContext is like OpenLoad but honors ctx for cancellation. The data is
+// sent with chunked transfer encoding and compressed on the fly if opts.Compression
+// is set. Like a streamed Load, the request cannot be retried once data was sent.
+func (c *Client) OpenLoadContext(ctx context.Context, table string, opts LoadOptions) *LoadWriter {
+	opts.Streaming = true
+
+	pr, pw := io.Pipe()
+	w := &LoadWriter{pw: pw, done: make(chan struct{})}
+	go func() {
+		defer close(w.done)
+		w.resp, w.err = c.LoadContext(ctx, table, pr, opts)
+
+		// Fail pending and later writes if the load ended before all data was written
+		if w.err != nil {
+			pr.CloseWithError(w.err)
+		} else {
+			pr.CloseWithError(errLoadFinished)
+		}
+	}()
+	return w
+}
+
+// Write sends p as part of the request body. It fails once the load has ended,
+// e.g. because StarRocks rejected the request.
+func (w *LoadWriter) Write(p []byte) (int, error) {
+	return w.pw.Write(p)
+}
+
+// Close ends the request body and waits for the load to finish, returning its error
+func (w *LoadWriter) Close() error {
+	w.once.Do(func() { w.pw.Close() })
+	<-w.done
+	return w.err
+}
+
+// Abort cancels the load by failing the request body with err, StarRocks discards the data written so far
+func (w *LoadWriter) Abort(err error) error {
+	if err == nil {
+		err = errors.New("stream load aborted")
+	}
+	w.once.Do(func() { w.pw.CloseWithError(err) })
+	<-w.done
+	return w.err
+}
+
+// Response returns the response of the load, it is nil until Close or Abort returned
+func (w *LoadWriter) Response() *LoadResponse {
+	select {
+	case <-w.done:
+		return w.resp
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,71 @@
+package streamload
+
+import (
+	"compress/gzip"
+	"encoding/csv"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestOpenLoad(t *testing.T) {
+	var body string
+	var encoding []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		encoding = r.TransferEncoding
+		zr, err := gzip.NewReader(r.Body)
+		if err != nil {
+			t.Errorf("body is not gzip compressed: %v", err)
+			return
+		}
+		data, _ := io.ReadAll(zr)
+		body = string(data)
+		w.Write([]byte(`{"Status":"Success","NumberLoadedRows":2}`))
+	}))
+	defer server.Close()
+
+	client := newTestClient(t, server)
+	writer := client.OpenLoad("users", LoadOptions{Format: FormatCSV, Compression: CompressionGZIP})
+	csvWriter := csv.NewWriter(writer)
+	csvWriter.Write([]string{"1", "Alice"})
+	csvWriter.Write([]string{"2", "Bob"})
+	csvWriter.Flush()
+	if err := csvWriter.Error(); err != nil {
+		t.Fatalf("unexpected write error: %v", err)
+	}
+	if writer.Response() != nil {
+		t.Errorf("expected no response before Close")
+	}
+
+	if err := writer.Close(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if body != "1,Alice\n2,Bob\n" {
+		t.Errorf("unexpected body %q", body)
+	}
+	if len(encoding) != 1 || encoding[0] != "chunked" {
+		t.Errorf("expected chunked transfer encoding, got %v", encoding)
+	}
+	if resp := writer.Response(); resp == nil || resp.NumberLoadedRows != 2 {
+		t.Errorf("unexpected response %+v", resp)
+	}
+}
+
+func TestOpenLoad_RejectedRequestFailsWrites(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte("access denied"))
+	}))
+	defer server.Close()
+
+	client := newTestClient(t, server)
+	writer := client.OpenLoad("users", LoadOptions{})
+	if _, err := writer.Write([]byte("1,a\n")); !errors.Is(err, ErrAuthFailed) {
+		t.Errorf("expected the write to fail with ErrAuthFailed, got %v", err)
+	}
+	if err := writer.Close(); !errors.Is(err, ErrAuthFailed) {
+		t.Errorf("expected ErrAuthFailed, got %v", err)
+	}
+}
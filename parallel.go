@@ -0,0 +1,256 @@
+package streamload
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// ParallelConfig configures ParallelLoad
+type ParallelConfig struct {
+	// ChunkSize is the approximate size of a chunk in bytes, chunks end on a row
+	// boundary after reaching it. Defaults to 64 MiB.
+	ChunkSize int
+	// Concurrency is the number of chunks loaded concurrently, defaults to 4
+	Concurrency int
+}
+
+// ChunkResult reports the outcome of loading a single chunk of a ParallelLoad
+type ChunkResult struct {
+	Index    int   // Position of the chunk in the input
+	Offset   int64 // Byte offset of the chunk in the input, -1 for JSON arrays
+	Bytes    int
+	Label    string
+	Response *LoadResponse
+	Err      error
+}
+
+// ParallelLoad splits data into chunks on row boundaries and loads them concurrently
+func (c *Client) ParallelLoad(table string, data io.Reader, opts LoadOptions, config ParallelConfig) (*LoadResponse, []ChunkResult, error) {
+	return c.ParallelLoadContext(context.Background(), table, data, opts, config)
+}
+
+// ParallelLoadContext splits data into chunks on row boundaries and loads them
+// concurrently, each under the label "<label>-<index>" (a label is generated if
+// opts.Label is empty). CSV data is split on opts.RowDelimiter, JSON data on
+// newlines or, if it is an array, between its elements.
+//
+// Every chunk is a separate load, so a failure leaves the chunks loaded before it
+// in place. No further chunks are started after a failure. The returned response
+// sums the row and byte counts of all chunks; its Status is "Success" only if every
+// chunk succeeded, and the error joins the errors of the failed chunks.
+func (c *Client) ParallelLoadContext(ctx context.Context, table string, data io.Reader, opts LoadOptions, config ParallelConfig) (*LoadResponse, []ChunkResult, error) {
+	if config.ChunkSize <= 0 {
+		config.ChunkSize = 64 * 1024 * 1024
+	}
+	if config.Concurrency <= 0 {
+		config.Concurrency = 4
+	}
+	if opts.Label == "" {
+		opts.Label = newLabel()
+	}
+	opts.Streaming = false
+
+	chunks, err := newChunker(newContextReader(ctx, data), opts, config.ChunkSize)
+	if err != nil {
+		return nil, nil, err
+	}
+	if chunks.jsonArray {
+		opts.StripOuterArray = true
+	}
+
+	start := time.Now()
+	var (
+		mu      sync.Mutex
+		wg      sync.WaitGroup
+		results []ChunkResult
+		failed  bool
+	)
+	sem := make(chan struct{}, config.Concurrency)
+
+	var readErr error
+	for index := 0; ; index++ {
+		sem <- struct{}{}
+		mu.Lock()
+		stop := failed
+		mu.Unlock()
+		if stop || ctx.Err() != nil {
+			<-sem
+			break
+		}
+
+		chunk, offset, err := chunks.next()
+		if err != nil {
+			<-sem
+			if err != io.EOF {
+				readErr = fmt.Errorf("failed to read chunk %d: %w", index, err)
+			}
+			break
+		}
+
+		chunkOpts := opts
+		chunkOpts.Label = fmt.Sprintf("%s-%d", opts.Label, index)
+		mu.Lock()
+		results = append(results, ChunkResult{Index: index, Offset: offset, Bytes: len(chunk), Label: chunkOpts.Label})
+		mu.Unlock()
+
+		wg.Add(1)
+		go func(index int, chunk []byte, opts LoadOptions) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			resp, err := c.LoadContext(ctx, table, bytes.NewReader(chunk), opts)
+			if err != nil && c.logger != nil {
+				c.logger.Printf("[DEBUG] ParallelLoad: chunk %d (%s) failed: %v", index, opts.Label, err)
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			results[index].Response = resp
+			results[index].Err = err
+			if err != nil {
+				failed = true
+			}
+		}(index, chunk, chunkOpts)
+	}
+	wg.Wait()
+
+	resp := &LoadResponse{Label: opts.Label, Status: "Success"}
+	var errs []error
+	if readErr != nil {
+		errs = append(errs, readErr)
+	}
+	failedChunks := 0
+	for _, result := range results {
+		if result.Err != nil {
+			failedChunks++
+			errs = append(errs, fmt.Errorf("chunk %d (%s): %w", result.Index, result.Label, result.Err))
+		}
+		if r := result.Response; r != nil {
+			resp.NumberTotalRows += r.NumberTotalRows
+			resp.NumberLoadedRows += r.NumberLoadedRows
+			resp.NumberFilteredRows += r.NumberFilteredRows
+			resp.NumberUnselectedRows += r.NumberUnselectedRows
+			resp.LoadBytes += r.LoadBytes
+			if resp.ErrorURL == "" {
+				resp.ErrorURL = r.ErrorURL
+			}
+		}
+	}
+	if err := ctx.Err(); err != nil && len(errs) == 0 {
+		errs = append(errs, err)
+	}
+	resp.LoadTimeMs = int(time.Since(start).Milliseconds())
+
+	err = errors.Join(errs...)
+	if err != nil {
+		resp.Status = "Fail"
+		resp.Message = fmt.Sprintf("%d of %d chunks failed", failedChunks, len(results))
+	} else {
+		resp.Message = fmt.Sprintf("loaded %d chunks", len(results))
+	}
+	return resp, results, err
+}
+
+// chunker splits an input into chunks that end on row boundaries
+type chunker struct {
+	r         *bufio.Reader
+	delim     []byte
+	size      int
+	offset    int64
+	jsonArray bool
+	decoder   *json.Decoder
+}
+
+// newChunker creates a chunker for data in the format of opts
+func newChunker(data io.Reader, opts LoadOptions, size int) (*chunker, error) {
+	c := &chunker{r: bufio.NewReader(data), size: size, delim: []byte("\n")}
+	if opts.Format != FormatJSON {
+		if opts.RowDelimiter != "" {
+			c.delim = []byte(opts.RowDelimiter)
+		}
+		return c, nil
+	}
+
+	// Skip leading whitespace to tell a JSON array from newline-delimited objects
+	for {
+		b, err := c.r.ReadByte()
+		if err == io.EOF {
+			return c, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if b == ' ' || b == '\t' || b == '\r' || b == '\n' {
+			continue
+		}
+		c.r.UnreadByte()
+		if b == '[' {
+			c.jsonArray = true
+			c.decoder = json.NewDecoder(c.r)
+			if _, err := c.decoder.Token(); err != nil {
+				return nil, fmt.Errorf("failed to parse JSON array: %w", err)
+			}
+		}
+		return c, nil
+	}
+}
+
+// next returns the next chunk and its byte offset, or io.EOF once the input is exhausted
+func (c *chunker) next() ([]byte, int64, error) {
+	if c.jsonArray {
+		return c.nextArray()
+	}
+
+	var buf bytes.Buffer
+	last := c.delim[len(c.delim)-1]
+	for buf.Len() < c.size || !bytes.HasSuffix(buf.Bytes(), c.delim) {
+		line, err := c.r.ReadSlice(last)
+		buf.Write(line)
+		if err == bufio.ErrBufferFull {
+			continue
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, 0, err
+		}
+	}
+
+	if len(bytes.TrimSpace(buf.Bytes())) == 0 {
+		return nil, 0, io.EOF
+	}
+	offset := c.offset
+	c.offset += int64(buf.Len())
+	return buf.Bytes(), offset, nil
+}
+
+// nextArray returns the next chunk of the elements of a JSON array
+func (c *chunker) nextArray() ([]byte, int64, error) {
+	var buf bytes.Buffer
+	for buf.Len() < c.size && c.decoder.More() {
+		var elem json.RawMessage
+		if err := c.decoder.Decode(&elem); err != nil {
+			return nil, 0, fmt.Errorf("failed to parse JSON array: %w", err)
+		}
+		if buf.Len() == 0 {
+			buf.WriteByte('[')
+		} else {
+			buf.WriteByte(',')
+		}
+		buf.Write(elem)
+	}
+
+	if buf.Len() == 0 {
+		return nil, 0, io.EOF
+	}
+	buf.WriteByte(']')
+	return buf.Bytes(), -1, nil
+}
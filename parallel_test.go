@@ -0,0 +1,88 @@
+package streamload
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestChunker_RowBoundaries(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		opts  LoadOptions
+		size  int
+		want  []string
+	}{
+		{"csv", "1,a\n2,b\n3,c\n", LoadOptions{}, 8, []string{"1,a\n2,b\n", "3,c\n"}},
+		{"custom delimiter", "1,a||2,b||3,c", LoadOptions{RowDelimiter: "||"}, 8, []string{"1,a||2,b||", "3,c"}},
+		{"ndjson", "{\"id\":1}\n{\"id\":2}\n{\"id\":3}", LoadOptions{Format: FormatJSON}, 10, []string{"{\"id\":1}\n{\"id\":2}\n", "{\"id\":3}"}},
+		{"json array", ` [{"id":1}, {"id":2}, {"id":3}]`, LoadOptions{Format: FormatJSON}, 10, []string{`[{"id":1},{"id":2}]`, `[{"id":3}]`}},
+	}
+
+	for _, tt := range tests {
+		chunks, err := newChunker(strings.NewReader(tt.input), tt.opts, tt.size)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tt.name, err)
+		}
+		var got []string
+		for {
+			chunk, _, err := chunks.next()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				t.Fatalf("%s: unexpected error: %v", tt.name, err)
+			}
+			got = append(got, string(chunk))
+		}
+		if strings.Join(got, "|") != strings.Join(tt.want, "|") {
+			t.Errorf("%s: expected chunks %q, got %q", tt.name, tt.want, got)
+		}
+	}
+}
+
+func TestParallelLoad(t *testing.T) {
+	var mu sync.Mutex
+	var labels []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		labels = append(labels, r.Header.Get("label"))
+		mu.Unlock()
+		if strings.Contains(string(body), "bad") {
+			w.Write([]byte(`{"Status":"Fail","Message":"too many filtered rows","NumberTotalRows":1,"NumberFilteredRows":1}`))
+			return
+		}
+		rows := strings.Count(string(body), "\n")
+		fmt.Fprintf(w, `{"Status":"Success","NumberTotalRows":%d,"NumberLoadedRows":%d}`, rows, rows)
+	}))
+	defer server.Close()
+
+	client := newTestClient(t, server)
+	resp, results, err := client.ParallelLoad("users", strings.NewReader("1,a\n2,b\n3,c\n4,d\n5,e\n"),
+		LoadOptions{Label: "big"}, ParallelConfig{ChunkSize: 8, Concurrency: 2})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.Status != "Success" || resp.NumberLoadedRows != 5 || resp.NumberTotalRows != 5 {
+		t.Errorf("unexpected aggregated response %+v", resp)
+	}
+	if len(results) != 3 || results[1].Offset != 8 || results[2].Bytes != 4 {
+		t.Errorf("unexpected chunk results %+v", results)
+	}
+	sort.Strings(labels)
+	if strings.Join(labels, ",") != "big-0,big-1,big-2" {
+		t.Errorf("unexpected labels %v", labels)
+	}
+
+	resp, _, err = client.ParallelLoad("users", strings.NewReader("1,a\nbad\n"), LoadOptions{}, ParallelConfig{ChunkSize: 1, Concurrency: 1})
+	if err == nil || resp.Status != "Fail" || resp.NumberFilteredRows != 1 {
+		t.Errorf("expected a failed aggregated response, got %+v, %v", resp, err)
+	}
+}
@@ -0,0 +1,271 @@
+package streamload
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http/httptrace"
+	"sync"
+)
+
+// errPayloadConsumed is returned when a streamed payload would have to be sent twice
+var errPayloadConsumed = errors.New("streamed payload was already consumed and cannot be resent")
+
+// errNoContinue stops the body of a request that was answered without "100 Continue"
+var errNoContinue = errors.New("request was answered before its body was requested")
+
+// payload provides the request body for the FE request, the BE redirect and every retry
+type payload interface {
+	// open returns a reader positioned at the start of the body
+	open() (io.Reader, error)
+	// size returns the body length in bytes, or -1 if it is unknown
+	size() int64
+	// replayable reports whether open can still return the whole body
+	replayable() bool
+	// close releases resources held by the payload
+	close() error
+}
+
+// bytesPayload is a payload buffered in memory
+type bytesPayload []byte
+
+func (p bytesPayload) open() (io.Reader, error) { return bytes.NewReader(p), nil }
+
+func (p bytesPayload) size() int64 { return int64(len(p)) }
+
+func (p bytesPayload) replayable() bool { return true }
+
+func (p bytesPayload) close() error { return nil }
+
+// sectionPayload sends a section of an io.ReaderAt, such as an *os.File, without copying it
+type sectionPayload struct {
+	r   io.ReaderAt
+	off int64
+	n   int64
+}
+
+func (p *sectionPayload) open() (io.Reader, error) { return io.NewSectionReader(p.r, p.off, p.n), nil }
+
+func (p *sectionPayload) size() int64 { return p.n }
+
+func (p *sectionPayload) replayable() bool { return true }
+
+func (p *sectionPayload) close() error { return nil }
+
+// seekerPayload sends an io.ReadSeeker from its initial offset, seeking back for every attempt.
+// Readers returned by earlier calls to open are invalidated, since the transport may still
+// read from them in the background after a response was received.
+type seekerPayload struct {
+	mu  sync.Mutex
+	rs  io.ReadSeeker
+	off int64
+	n   int64
+	gen int
+}
+
+func (p *seekerPayload) open() (io.Reader, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if _, err := p.rs.Seek(p.off, io.SeekStart); err != nil {
+		return nil, fmt.Errorf("failed to rewind data: %w", err)
+	}
+	p.gen++
+	return &seekerReader{p: p, gen: p.gen, remaining: p.n}, nil
+}
+
+func (p *seekerPayload) size() int64 { return p.n }
+
+func (p *seekerPayload) replayable() bool { return true }
+
+func (p *seekerPayload) close() error { return nil }
+
+// seekerReader reads one attempt's body from a seekerPayload
+type seekerReader struct {
+	p         *seekerPayload
+	gen       int
+	remaining int64
+}
+
+func (r *seekerReader) Read(b []byte) (int, error) {
+	r.p.mu.Lock()
+	defer r.p.mu.Unlock()
+	if r.gen != r.p.gen {
+		return 0, errors.New("data was rewound for another attempt")
+	}
+	if r.remaining <= 0 {
+		return 0, io.EOF
+	}
+	if int64(len(b)) > r.remaining {
+		b = b[:r.remaining]
+	}
+	n, err := r.p.rs.Read(b)
+	r.remaining -= int64(n)
+	return n, err
+}
+
+// newSeekablePayload sends data directly if it can be rewound, reporting false otherwise.
+// The payload covers data from its current offset to the end.
+func newSeekablePayload(data io.Reader) (payload, bool) {
+	seeker, ok := data.(io.ReadSeeker)
+	if !ok {
+		return nil, false
+	}
+
+	off, err := seeker.Seek(0, io.SeekCurrent)
+	if err != nil {
+		// Not actually seekable, e.g. an *os.File for a pipe
+		return nil, false
+	}
+	end, err := seeker.Seek(0, io.SeekEnd)
+	if err != nil {
+		return nil, false
+	}
+	if _, err := seeker.Seek(off, io.SeekStart); err != nil {
+		return nil, false
+	}
+
+	if readerAt, ok := data.(io.ReaderAt); ok {
+		return &sectionPayload{r: readerAt, off: off, n: end - off}, true
+	}
+	return &seekerPayload{rs: seeker, off: off, n: end - off}, true
+}
+
+// streamPayload pipes the source through the compressor straight onto the wire.
+// The source is only pulled once the transport starts sending the body; the FE
+// request additionally holds it back with a continueGate, so an FE that answers
+// with a 307 leaves it untouched for the redirected BE request.
+type streamPayload struct {
+	mu      sync.Mutex
+	ctx     context.Context
+	source  io.Reader
+	comp    CompressionType
+	reader  io.ReadCloser
+	started bool
+}
+
+// newStreamPayload creates a payload that streams data without buffering it
+func newStreamPayload(ctx context.Context, data io.Reader, compression CompressionType) *streamPayload {
+	return &streamPayload{ctx: ctx, source: data, comp: compression}
+}
+
+func (p *streamPayload) open() (io.Reader, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.started {
+		return nil, errPayloadConsumed
+	}
+	return &lazyStreamReader{p: p}, nil
+}
+
+func (p *streamPayload) size() int64 { return -1 }
+
+func (p *streamPayload) replayable() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return !p.started
+}
+
+func (p *streamPayload) close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.reader != nil {
+		return p.reader.Close()
+	}
+	return nil
+}
+
+// start begins reading the source, compressing it in a goroutine if required
+func (p *streamPayload) start() io.Reader {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.reader != nil {
+		return p.reader
+	}
+	p.started = true
+
+	source := newContextReader(p.ctx, p.source)
+	if p.comp == CompressionNone {
+		p.reader = io.NopCloser(source)
+		return p.reader
+	}
+
+	pr, pw := io.Pipe()
+	go func() {
+		writer, err := newCompressWriter(pw, p.comp)
+		if err != nil {
+			pw.CloseWithError(err)
+			return
+		}
+		if _, err := io.Copy(writer, source); err != nil {
+			pw.CloseWithError(err)
+			return
+		}
+		pw.CloseWithError(writer.Close())
+	}()
+	p.reader = pr
+	return p.reader
+}
+
+// lazyStreamReader defers starting the stream until the first read
+type lazyStreamReader struct {
+	p *streamPayload
+	r io.Reader
+}
+
+func (r *lazyStreamReader) Read(b []byte) (int, error) {
+	if r.r == nil {
+		r.r = r.p.start()
+	}
+	return r.r.Read(b)
+}
+
+// continueGate holds back the body of a request until the server asks for it with
+// "100 Continue". The transport alone does not guarantee this: it sends the body
+// right away if its ExpectContinueTimeout is 0, and once that timeout expired.
+type continueGate struct {
+	once   sync.Once
+	done   chan struct{}
+	reject bool
+}
+
+func newContinueGate() *continueGate {
+	return &continueGate{done: make(chan struct{})}
+}
+
+// open lets the body through, it is called from the Got100Continue trace hook
+func (g *continueGate) open() {
+	g.once.Do(func() { close(g.done) })
+}
+
+// close fails the body if it was not let through yet, called once a response arrived
+func (g *continueGate) close() {
+	g.once.Do(func() {
+		g.reject = true
+		close(g.done)
+	})
+}
+
+// trace attaches the gate to the request context
+func (g *continueGate) trace(ctx context.Context) context.Context {
+	return httptrace.WithClientTrace(ctx, &httptrace.ClientTrace{Got100Continue: g.open})
+}
+
+// reader wraps a body so that reading blocks until the gate is opened or closed
+func (g *continueGate) reader(r io.Reader) io.Reader {
+	return &gatedReader{g: g, r: r}
+}
+
+type gatedReader struct {
+	g *continueGate
+	r io.Reader
+}
+
+func (r *gatedReader) Read(p []byte) (int, error) {
+	<-r.g.done
+	if r.g.reject {
+		return 0, errNoContinue
+	}
+	return r.r.Read(p)
+}
@@ -0,0 +1,202 @@
+package streamload
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoad_StreamingFollowsRedirect(t *testing.T) {
+	var received string
+	var transferEncoding []string
+	be := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reader, err := gzip.NewReader(r.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"Status":"Fail","Message":"not gzip"}`))
+			return
+		}
+		body, _ := io.ReadAll(reader)
+		received = string(body)
+		transferEncoding = r.TransferEncoding
+		w.Write([]byte(`{"Status":"Success"}`))
+	}))
+	defer be.Close()
+
+	fe := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, be.URL+r.URL.Path, http.StatusTemporaryRedirect)
+	}))
+	defer fe.Close()
+
+	client := newTestClient(t, fe)
+	data := strings.Repeat(`{"id":1,"name":"Alice"}`+"\n", 10000)
+
+	// A strings.Reader can only be read once, so the FE request must not consume it
+	_, err := client.Load("users", strings.NewReader(data), LoadOptions{
+		Format:      FormatJSON,
+		Compression: CompressionGZIP,
+		Streaming:   true,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if received != data {
+		t.Errorf("BE received %d bytes, expected %d", len(received), len(data))
+	}
+	if len(transferEncoding) == 0 || transferEncoding[0] != "chunked" {
+		t.Errorf("expected chunked transfer encoding, got %v", transferEncoding)
+	}
+}
+
+func TestLoad_StreamingWaitsForSlowRedirect(t *testing.T) {
+	tests := []struct {
+		name      string
+		transport *http.Transport
+	}{
+		{name: "FE slower than the continue timeout", transport: &http.Transport{ExpectContinueTimeout: 20 * time.Millisecond}},
+		{name: "transport without continue timeout", transport: &http.Transport{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer tt.transport.CloseIdleConnections()
+			var received string
+			be := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, _ := io.ReadAll(r.Body)
+				received = string(body)
+				w.Write([]byte(`{"Status":"Success"}`))
+			}))
+			defer be.Close()
+
+			fe := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				time.Sleep(200 * time.Millisecond)
+				http.Redirect(w, r, be.URL+r.URL.Path, http.StatusTemporaryRedirect)
+			}))
+			defer fe.Close()
+
+			client := newTestClient(t, fe)
+			client.SetHTTPClient(&http.Client{
+				Transport: tt.transport,
+				CheckRedirect: func(req *http.Request, via []*http.Request) error {
+					return http.ErrUseLastResponse
+				},
+			})
+
+			data := struct{ io.Reader }{strings.NewReader("1,a\n2,b")}
+			if _, err := client.Load("users", data, LoadOptions{Streaming: true}); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if received != "1,a\n2,b" {
+				t.Errorf("BE received %q", received)
+			}
+		})
+	}
+}
+
+func TestLoad_StreamingIsNotRetriedAfterSend(t *testing.T) {
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		io.Copy(io.Discard, r.Body)
+		w.WriteHeader(http.StatusServiceUnavailable)
+		w.Write([]byte(`{"Status":"Fail","Message":"busy"}`))
+	}))
+	defer server.Close()
+
+	client := newTestClient(t, server)
+	client.SetRetryPolicy(RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond})
+
+	// Hide the Seek method so that the data is actually streamed
+	data := struct{ io.Reader }{strings.NewReader("1,a")}
+	_, err := client.Load("users", data, LoadOptions{Streaming: true})
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	if attempts != 1 {
+		t.Errorf("expected a single attempt for a consumed stream, got %d", attempts)
+	}
+}
+
+func TestStreamPayload_ReplayableUntilRead(t *testing.T) {
+	p := newStreamPayload(t.Context(), strings.NewReader("abc"), CompressionNone)
+	defer p.close()
+
+	if _, err := p.open(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !p.replayable() {
+		t.Fatal("payload should stay replayable until it is read")
+	}
+
+	r, _ := p.open()
+	body, _ := io.ReadAll(r)
+	if string(body) != "abc" {
+		t.Errorf("expected abc, got %q", body)
+	}
+	if p.replayable() {
+		t.Error("payload should not be replayable after it was read")
+	}
+	if _, err := p.open(); err != errPayloadConsumed {
+		t.Errorf("expected errPayloadConsumed, got %v", err)
+	}
+}
+
+func TestLoad_SeekableInputIsSentDirectly(t *testing.T) {
+	var bodies []string
+	var lengths []int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(body))
+		lengths = append(lengths, r.ContentLength)
+		if len(bodies) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			w.Write([]byte(`{"Status":"Fail","Message":"busy"}`))
+			return
+		}
+		w.Write([]byte(`{"Status":"Success"}`))
+	}))
+	defer server.Close()
+
+	client := newTestClient(t, server)
+	client.SetRetryPolicy(RetryPolicy{MaxAttempts: 2, InitialBackoff: time.Millisecond})
+
+	// Only the data after the current offset is loaded
+	data := strings.NewReader("header\n1,a\n2,b")
+	data.Seek(int64(len("header\n")), io.SeekStart)
+
+	if _, err := client.Load("users", data, LoadOptions{Streaming: true}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(bodies) != 2 || bodies[0] != "1,a\n2,b" || bodies[1] != "1,a\n2,b" {
+		t.Errorf("expected the data to be resent from its initial offset, got %q", bodies)
+	}
+	if lengths[0] != 7 {
+		t.Errorf("expected Content-Length 7, got %d", lengths[0])
+	}
+}
+
+func TestSeekerPayload_InvalidatesPreviousReaders(t *testing.T) {
+	// Hide ReadAt so that the seeking implementation is used
+	data := struct{ io.ReadSeeker }{strings.NewReader("abc")}
+	body, ok := newSeekablePayload(data)
+	if !ok {
+		t.Fatal("expected a seekable payload")
+	}
+	if _, ok := body.(*seekerPayload); !ok {
+		t.Fatalf("expected *seekerPayload, got %T", body)
+	}
+
+	first, _ := body.open()
+	second, _ := body.open()
+	if _, err := first.Read(make([]byte, 1)); err == nil {
+		t.Error("expected a stale reader to fail")
+	}
+	content, _ := io.ReadAll(second)
+	if string(content) != "abc" {
+		t.Errorf("expected abc, got %q", content)
+	}
+}
@@ -0,0 +1,259 @@
+package streamload
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+// maxRejectedRowLength is the longest error log entry FetchRejectedRows accepts
+const maxRejectedRowLength = 16 * 1024 * 1024
+
+// RejectedRow is a single entry of the error log of a load
+type RejectedRow struct {
+	Reason string // Why StarRocks rejected the row
+	Row    string // The rejected row as reported by StarRocks, empty if the entry has none
+	Column string // Name of the offending column, if the reason mentions one
+	Raw    string // The unparsed error log entry
+}
+
+// rejectedColumnPatterns extract the column name from the reasons reported by different StarRocks versions
+var rejectedColumnPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`(?i)column\s*'([^']+)'`),
+	regexp.MustCompile(`(?i)column\s*\(([^)]+)\)`),
+	regexp.MustCompile(`(?i)column\s*[=:]\s*([^\s,;]+?)[.,;]?(?:\s|$)`),
+	regexp.MustCompile(`(?i)type of '([^']+)'`),
+}
+
+// FetchRejectedRows downloads the error log referenced by resp.ErrorURL and parses its entries.
+// It returns no rows if the response has no ErrorURL.
+func (c *Client) FetchRejectedRows(resp *LoadResponse) ([]RejectedRow, error) {
+	return c.FetchRejectedRowsContext(context.Background(), resp)
+}
+
+// FetchRejectedRowsContext is like FetchRejectedRows but honors ctx for cancellation
+func (c *Client) FetchRejectedRowsContext(ctx context.Context, resp *LoadResponse) ([]RejectedRow, error) {
+	if resp == nil || resp.ErrorURL == "" {
+		return nil, nil
+	}
+
+	// The error log is served by the BE, which may need the same address translation as redirects
+	location, err := c.mapLocation(resp.ErrorURL)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", location, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create error log request: %w", err)
+	}
+	req.SetBasicAuth(c.username, c.password)
+
+	httpResp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch error log: %w", err)
+	}
+	defer httpResp.Body.Close()
+
+	if httpResp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(httpResp.Body, 4096))
+		return nil, fmt.Errorf("fetch error log failed with status %d: %s", httpResp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	return parseRejectedRows(httpResp.Body)
+}
+
+// parseRejectedRows parses an error log with one entry per line
+func parseRejectedRows(r io.Reader) ([]RejectedRow, error) {
+	var rows []RejectedRow
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), maxRejectedRowLength)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		rows = append(rows, parseRejectedRow(line))
+	}
+	if err := scanner.Err(); err != nil {
+		return rows, fmt.Errorf("failed to read error log: %w", err)
+	}
+	return rows, nil
+}
+
+// parseRejectedRow parses a single error log entry. Newer StarRocks versions write
+// "Error: <reason>. Row: <row>", older ones "Reason: <reason>. src line: [<row>]; ".
+func parseRejectedRow(line string) RejectedRow {
+	row := RejectedRow{Raw: line}
+
+	text := strings.TrimSpace(line)
+	for _, prefix := range []string{"Error:", "Reason:"} {
+		if strings.HasPrefix(text, prefix) {
+			text = strings.TrimSpace(strings.TrimPrefix(text, prefix))
+			break
+		}
+	}
+
+	if idx := strings.Index(text, "src line: ["); idx >= 0 {
+		row.Reason = text[:idx]
+		data := text[idx+len("src line: ["):]
+		data = strings.TrimSuffix(strings.TrimRight(data, " "), ";")
+		row.Row = strings.TrimSuffix(data, "]")
+	} else if idx := strings.Index(text, "Row: "); idx >= 0 {
+		row.Reason = text[:idx]
+		row.Row = text[idx+len("Row: "):]
+	} else {
+		row.Reason = text
+	}
+	row.Reason = strings.TrimRight(strings.TrimSpace(row.Reason), ".")
+
+	for _, pattern := range rejectedColumnPatterns {
+		if m := pattern.FindStringSubmatch(row.Reason); m != nil {
+			row.Column = m[1]
+			break
+		}
+	}
+	return row
+}
+
+// attachRejectedRows fetches the rejected rows of a load if opts.FetchRejectedRows is
+// set and attaches them to the response and to a returned *LoadError
+func (c *Client) attachRejectedRows(ctx context.Context, resp *LoadResponse, err error, opts LoadOptions) {
+	if !opts.FetchRejectedRows || resp == nil || resp.NumberFilteredRows == 0 || resp.ErrorURL == "" {
+		return
+	}
+
+	rows, fetchErr := c.FetchRejectedRowsContext(ctx, resp)
+	if fetchErr != nil {
+		if c.logger != nil {
+			c.logger.Printf("[DEBUG] Failed to fetch rejected rows from %s: %v", resp.ErrorURL, fetchErr)
+		}
+		return
+	}
+
+	resp.RejectedRows = rows
+	var loadErr *LoadError
+	if errors.As(err, &loadErr) {
+		loadErr.RejectedRows = rows
+	}
+}
+
+// RejectedElement correlates a rejected row with the element of the slice passed to a struct loader
+type RejectedElement struct {
+	Index int         // Index of the element in the input slice
+	Value interface{} // The element itself
+	RejectedRow
+}
+
+// matchRejectedCSV maps rejected rows to the records of CSV data marshaled from structs,
+// comparing the raw line or, for rows reported as "[v1, v2]", the individual fields.
+// Identical records are matched in input order. Rows without a match are omitted.
+func matchRejectedCSV(structs interface{}, data []byte, rows []RejectedRow) []RejectedElement {
+	index := newRowIndex()
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.FieldsPerRecord = -1
+	start := int64(0)
+	for i := 0; ; i++ {
+		fields, err := reader.Read()
+		if err != nil {
+			break
+		}
+		end := reader.InputOffset()
+		line := strings.TrimRight(string(data[start:end]), "\r\n")
+		start = end
+		index.add(i, line, "["+strings.Join(fields, ", ")+"]")
+	}
+
+	return index.match(rows, strings.TrimSpace, sliceElement(structs))
+}
+
+// matchRejectedJSON maps rejected rows to the elements of structs marshaled as JSON,
+// comparing objects independently of key order and whitespace
+func matchRejectedJSON(structs interface{}, rows []RejectedRow) []RejectedElement {
+	index := newRowIndex()
+	val := reflect.Indirect(reflect.ValueOf(structs))
+	for i := 0; i < val.Len(); i++ {
+		data, err := json.Marshal(val.Index(i).Interface())
+		if err != nil {
+			continue
+		}
+		index.add(i, canonicalJSON(string(data)))
+	}
+
+	return index.match(rows, canonicalJSON, sliceElement(structs))
+}
+
+// canonicalJSON re-encodes a JSON value with sorted keys, returning s unchanged if it is not JSON
+func canonicalJSON(s string) string {
+	var v interface{}
+	if err := json.Unmarshal([]byte(s), &v); err != nil {
+		return strings.TrimSpace(s)
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return strings.TrimSpace(s)
+	}
+	return string(data)
+}
+
+// rowIndex looks up input indices by row content
+type rowIndex struct {
+	indices map[string][]int
+	used    map[int]bool
+}
+
+func newRowIndex() *rowIndex {
+	return &rowIndex{indices: make(map[string][]int), used: make(map[int]bool)}
+}
+
+// add registers the keys under which the element at index i may be reported
+func (x *rowIndex) add(i int, keys ...string) {
+	for _, key := range keys {
+		x.indices[key] = append(x.indices[key], i)
+	}
+}
+
+// match returns the elements of rows found in the index, each input index is matched at most once
+func (x *rowIndex) match(rows []RejectedRow, normalize func(string) string, value func(i int) interface{}) []RejectedElement {
+	var elements []RejectedElement
+	for _, row := range rows {
+		if row.Row == "" {
+			continue
+		}
+		for _, i := range x.indices[normalize(row.Row)] {
+			if x.used[i] {
+				continue
+			}
+			x.used[i] = true
+			elements = append(elements, RejectedElement{Index: i, Value: value(i), RejectedRow: row})
+			break
+		}
+	}
+	return elements
+}
+
+// sliceElement returns a function returning the elements of a slice
+func sliceElement(structs interface{}) func(i int) interface{} {
+	val := reflect.Indirect(reflect.ValueOf(structs))
+	return func(i int) interface{} { return val.Index(i).Interface() }
+}
+
+// attachRejectedElements attaches the rejected elements to the response and to a returned *LoadError
+func attachRejectedElements(resp *LoadResponse, err error, elements []RejectedElement) {
+	if resp != nil {
+		resp.RejectedElements = elements
+	}
+	var loadErr *LoadError
+	if errors.As(err, &loadErr) {
+		loadErr.RejectedElements = elements
+	}
+}
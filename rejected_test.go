@@ -0,0 +1,156 @@
+package streamload
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestParseRejectedRow(t *testing.T) {
+	tests := []struct {
+		line string
+		want RejectedRow
+	}{
+		{
+			line: "Error: Value count does not match column count. Expect 3, but got 2. Row: 1,a",
+			want: RejectedRow{Reason: "Value count does not match column count. Expect 3, but got 2", Row: "1,a"},
+		},
+		{
+			line: "Error: NULL value in non-nullable column 'name'. Row: [2, NULL]",
+			want: RejectedRow{Reason: "NULL value in non-nullable column 'name'", Row: "[2, NULL]", Column: "name"},
+		},
+		{
+			line: "Reason: column(age) value is incorrect. src line: [3,b,x]; ",
+			want: RejectedRow{Reason: "column(age) value is incorrect", Row: "3,b,x", Column: "age"},
+		},
+		{
+			line: "Reason: null value for not null column, column=id. src line: [,c]; ",
+			want: RejectedRow{Reason: "null value for not null column, column=id", Row: ",c", Column: "id"},
+		},
+		{
+			line: "some unexpected entry",
+			want: RejectedRow{Reason: "some unexpected entry"},
+		},
+	}
+
+	for _, tt := range tests {
+		got := parseRejectedRow(tt.line)
+		tt.want.Raw = tt.line
+		if got != tt.want {
+			t.Errorf("parseRejectedRow(%q) = %+v, want %+v", tt.line, got, tt.want)
+		}
+	}
+}
+
+func TestLoad_FetchRejectedRows(t *testing.T) {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/_load_error_log" {
+			w.Write([]byte("Error: Value count does not match column count. Expect 2, but got 1. Row: 2\n\n" +
+				"Error: NULL value in non-nullable column 'name'. Row: [3, NULL]\n"))
+			return
+		}
+		w.Write([]byte(`{"Status":"Fail","Message":"too many filtered rows","NumberFilteredRows":2,` +
+			`"ErrorURL":"` + server.URL + `/api/_load_error_log?file=error_log_1"}`))
+	}))
+	defer server.Close()
+
+	client := newTestClient(t, server)
+	_, err := client.Load("users", strings.NewReader("1,a\n2\n3,"), LoadOptions{FetchRejectedRows: true})
+
+	var loadErr *LoadError
+	if !errors.As(err, &loadErr) {
+		t.Fatalf("expected *LoadError, got %v", err)
+	}
+	if len(loadErr.RejectedRows) != 2 {
+		t.Fatalf("expected 2 rejected rows, got %+v", loadErr.RejectedRows)
+	}
+	if loadErr.RejectedRows[0].Row != "2" || loadErr.RejectedRows[1].Column != "name" {
+		t.Errorf("unexpected rejected rows: %+v", loadErr.RejectedRows)
+	}
+}
+
+func TestFetchRejectedRows_NoErrorURL(t *testing.T) {
+	client := NewClient("127.0.0.1", "1", "test_db", "root", "")
+	rows, err := client.FetchRejectedRowsContext(context.Background(), &LoadResponse{Status: "Success"})
+	if err != nil || rows != nil {
+		t.Errorf("expected no rows and no error, got %v, %v", rows, err)
+	}
+}
+
+// rejectingServer fails every load, reporting the given error log entries as rejected rows
+func rejectingServer(entries ...string) *httptest.Server {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/_load_error_log" {
+			w.Write([]byte(strings.Join(entries, "\n")))
+			return
+		}
+		w.Write([]byte(`{"Status":"Fail","Message":"too many filtered rows","NumberFilteredRows":` +
+			fmt.Sprint(len(entries)) + `,"ErrorURL":"` + server.URL + `/api/_load_error_log?file=error_log_1"}`))
+	}))
+	return server
+}
+
+func TestLoadStructsCSV_RejectedElements(t *testing.T) {
+	server := rejectingServer(
+		"Error: NULL value in non-nullable column 'age'. Row: [3, Carol, 0]",
+		"Error: Value count does not match column count. Row: 2,Bob,30",
+	)
+	defer server.Close()
+
+	users := []TestUser{{Id: 1, Name: "Alice", Age: 25}, {Id: 2, Name: "Bob", Age: 30}, {Id: 3, Name: "Carol"}}
+	client := newTestClient(t, server)
+	_, err := client.LoadStructsCSV("users", users, LoadOptions{FetchRejectedRows: true})
+
+	var loadErr *LoadError
+	if !errors.As(err, &loadErr) {
+		t.Fatalf("expected *LoadError, got %v", err)
+	}
+	if len(loadErr.RejectedElements) != 2 {
+		t.Fatalf("expected 2 rejected elements, got %+v", loadErr.RejectedElements)
+	}
+	if loadErr.RejectedElements[0].Index != 2 || loadErr.RejectedElements[0].Column != "age" {
+		t.Errorf("unexpected first element: %+v", loadErr.RejectedElements[0])
+	}
+	if loadErr.RejectedElements[1].Index != 1 || loadErr.RejectedElements[1].Value.(TestUser).Name != "Bob" {
+		t.Errorf("unexpected second element: %+v", loadErr.RejectedElements[1])
+	}
+}
+
+func TestLoadStructsJSON_RejectedElements(t *testing.T) {
+	server := rejectingServer(
+		`Error: Value is out of range. Row: {"age": 30, "name": "Bob", "id": 2}`,
+		`Error: Value is out of range. Row: {"id": 9, "name": "Nobody", "age": 1}`,
+	)
+	defer server.Close()
+
+	users := []*TestUser{{Id: 1, Name: "Alice", Age: 25}, {Id: 2, Name: "Bob", Age: 30}}
+	client := newTestClient(t, server)
+	_, err := client.LoadStructsJSON("users", users, LoadOptions{FetchRejectedRows: true})
+
+	var loadErr *LoadError
+	if !errors.As(err, &loadErr) {
+		t.Fatalf("expected *LoadError, got %v", err)
+	}
+	if len(loadErr.RejectedRows) != 2 {
+		t.Errorf("expected 2 rejected rows, got %d", len(loadErr.RejectedRows))
+	}
+	if len(loadErr.RejectedElements) != 1 || loadErr.RejectedElements[0].Index != 1 {
+		t.Errorf("expected only element 1 to be matched, got %+v", loadErr.RejectedElements)
+	}
+}
+
+func TestAttachRejectedElements_WrappedError(t *testing.T) {
+	loadErr := &LoadError{Op: "stream load", StatusCode: 200, Message: "too many filtered rows"}
+	elements := []RejectedElement{{Index: 1, RejectedRow: RejectedRow{Reason: "bad"}}}
+
+	attachRejectedElements(&LoadResponse{}, fmt.Errorf("load users: %w", loadErr), elements)
+	if len(loadErr.RejectedElements) != 1 {
+		t.Errorf("expected the rejected elements on the wrapped *LoadError, got %+v", loadErr.RejectedElements)
+	}
+}
@@ -0,0 +1,140 @@
+package streamload
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// RetryPolicy controls how failed requests are retried.
+// The zero value performs a single attempt.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts including the first one
+	MaxAttempts int
+	// InitialBackoff is the delay before the first retry
+	InitialBackoff time.Duration
+	// MaxBackoff caps the delay between attempts
+	MaxBackoff time.Duration
+	// Multiplier is applied to the delay after every retry, defaults to 2
+	Multiplier float64
+	// Jitter randomizes each delay by up to this fraction in both directions (e.g. 0.2 for ±20%)
+	Jitter float64
+	// Retryable decides whether a failed attempt should be retried, defaults to DefaultRetryable
+	Retryable func(result AttemptResult) bool
+}
+
+// AttemptResult describes the outcome of a single request attempt
+type AttemptResult struct {
+	Op         string // Operation name, e.g. "stream load" or "commit transaction"
+	Attempt    int    // 1-based attempt number
+	StatusCode int    // HTTP status code of the final response, 0 if no response was received
+	Status     string // StarRocks Status field of the response
+	Message    string // StarRocks Message field of the response
+	Err        error  // Error of the attempt, nil on success
+
+	final bool // The attempt cannot be repeated, e.g. because its streamed body was consumed
+}
+
+// DefaultRetryPolicy returns a policy with 3 attempts and exponential backoff starting at 1s
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts:    3,
+		InitialBackoff: time.Second,
+		MaxBackoff:     30 * time.Second,
+		Multiplier:     2,
+		Jitter:         0.2,
+	}
+}
+
+// DefaultRetryable retries transport errors, 5xx responses and StarRocks
+// statuses that are known to be transient ("Publish Timeout", "too many versions").
+// Context cancellation is never retried. A "Publish Timeout" load is already
+// committed; its retry finds the label used by the finished job and succeeds.
+func DefaultRetryable(result AttemptResult) bool {
+	if errors.Is(result.Err, context.Canceled) || errors.Is(result.Err, context.DeadlineExceeded) {
+		return false
+	}
+	if result.StatusCode == 0 {
+		return result.Err != nil
+	}
+	if result.StatusCode >= http.StatusInternalServerError {
+		return true
+	}
+	if result.Status == "Publish Timeout" {
+		return true
+	}
+	return strings.Contains(strings.ToLower(result.Message), "too many versions")
+}
+
+// attempts returns the effective number of attempts
+func (p RetryPolicy) attempts() int {
+	if p.MaxAttempts < 1 {
+		return 1
+	}
+	return p.MaxAttempts
+}
+
+// retryable classifies a failed attempt
+func (p RetryPolicy) retryable(result AttemptResult) bool {
+	if p.Retryable != nil {
+		return p.Retryable(result)
+	}
+	return DefaultRetryable(result)
+}
+
+// backoff returns the delay after the given failed attempt
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	multiplier := p.Multiplier
+	if multiplier <= 0 {
+		multiplier = 2
+	}
+
+	delay := float64(p.InitialBackoff) * math.Pow(multiplier, float64(attempt-1))
+	if p.MaxBackoff > 0 && delay > float64(p.MaxBackoff) {
+		delay = float64(p.MaxBackoff)
+	}
+	if p.Jitter > 0 {
+		delay += delay * p.Jitter * (2*rand.Float64() - 1)
+	}
+	if delay < 0 {
+		return 0
+	}
+	return time.Duration(delay)
+}
+
+// withRetry runs attempt until it succeeds, the retry policy gives up or ctx is done
+func (c *Client) withRetry(ctx context.Context, op string, attempt func() AttemptResult) error {
+	policy := c.retryPolicy
+	maxAttempts := policy.attempts()
+
+	for i := 1; ; i++ {
+		result := attempt()
+		if result.Err == nil {
+			return nil
+		}
+
+		result.Op = op
+		result.Attempt = i
+		if result.final || i >= maxAttempts || !policy.retryable(result) {
+			return result.Err
+		}
+
+		delay := policy.backoff(i)
+		if c.logger != nil {
+			c.logger.Printf("[DEBUG] %s attempt %d/%d failed: %v, retrying in %s", op, i, maxAttempts, result.Err, delay)
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return fmt.Errorf("%s aborted after %d attempts: %w (last error: %v)", op, i, ctx.Err(), result.Err)
+		case <-timer.C:
+		}
+	}
+}
@@ -0,0 +1,181 @@
+package streamload
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRetryPolicy_Backoff(t *testing.T) {
+	policy := RetryPolicy{InitialBackoff: 100 * time.Millisecond, MaxBackoff: time.Second, Multiplier: 2}
+
+	expected := []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 400 * time.Millisecond, 800 * time.Millisecond, time.Second}
+	for i, want := range expected {
+		if got := policy.backoff(i + 1); got != want {
+			t.Errorf("attempt %d: expected backoff %s, got %s", i+1, want, got)
+		}
+	}
+
+	policy.Jitter = 0.5
+	for i := 0; i < 100; i++ {
+		if got := policy.backoff(1); got < 50*time.Millisecond || got > 150*time.Millisecond {
+			t.Fatalf("jittered backoff %s out of range", got)
+		}
+	}
+}
+
+func TestDefaultRetryable(t *testing.T) {
+	cases := []struct {
+		name   string
+		result AttemptResult
+		want   bool
+	}{
+		{"transport error", AttemptResult{Err: errors.New("connection refused")}, true},
+		{"context canceled", AttemptResult{Err: context.Canceled}, false},
+		{"server error", AttemptResult{StatusCode: 503, Err: errors.New("unavailable")}, true},
+		{"publish timeout", AttemptResult{StatusCode: 200, Status: "Publish Timeout", Err: errors.New("failed")}, true},
+		{"too many versions", AttemptResult{StatusCode: 200, Status: "Fail", Message: "Too many versions. tablet_id: 1", Err: errors.New("failed")}, true},
+		{"label exists", AttemptResult{StatusCode: 200, Status: "Label Already Exists", Err: errors.New("failed")}, false},
+	}
+
+	for _, tc := range cases {
+		if got := DefaultRetryable(tc.result); got != tc.want {
+			t.Errorf("%s: expected %v, got %v", tc.name, tc.want, got)
+		}
+	}
+}
+
+func TestLoad_RetriesWithSameLabel(t *testing.T) {
+	var mu sync.Mutex
+	var labels []string
+	var bodies []string
+
+	be := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+
+		mu.Lock()
+		labels = append(labels, r.Header.Get("label"))
+		bodies = append(bodies, string(body))
+		attempt := len(labels)
+		mu.Unlock()
+
+		if attempt == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			w.Write([]byte(`{"Status":"Fail","Message":"busy"}`))
+			return
+		}
+		w.Write([]byte(`{"Status":"Success","NumberLoadedRows":1}`))
+	}))
+	defer be.Close()
+
+	fe := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, be.URL+r.URL.Path, http.StatusTemporaryRedirect)
+	}))
+	defer fe.Close()
+
+	client := newTestClient(t, fe)
+	client.SetRetryPolicy(RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond})
+
+	resp, err := client.Load("users", strings.NewReader("1,a"), LoadOptions{Format: FormatCSV})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.NumberLoadedRows != 1 {
+		t.Errorf("expected 1 loaded row, got %d", resp.NumberLoadedRows)
+	}
+
+	if len(labels) != 2 {
+		t.Fatalf("expected 2 attempts, got %d", len(labels))
+	}
+	if labels[0] == "" || labels[0] != labels[1] {
+		t.Errorf("expected the same generated label on every attempt, got %q", labels)
+	}
+	if bodies[0] != "1,a" || bodies[1] != "1,a" {
+		t.Errorf("expected the payload to be resent on retry, got %q", bodies)
+	}
+}
+
+func TestLoad_NoRetryByDefault(t *testing.T) {
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(`{"Status":"Fail","Message":"internal error"}`))
+	}))
+	defer server.Close()
+
+	client := newTestClient(t, server)
+	resp, err := client.Load("users", strings.NewReader("1,a"), LoadOptions{})
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	if resp == nil || resp.Message != "internal error" {
+		t.Errorf("expected the failed response to be returned, got %+v", resp)
+	}
+	if attempts != 1 {
+		t.Errorf("expected a single attempt, got %d", attempts)
+	}
+}
+
+func TestLoad_RetryFindsCommittedLabel(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  string // ExistingJobStatus of the retry
+		wantErr bool
+	}{
+		{name: "finished", status: "FINISHED"},
+		{name: "visible", status: "VISIBLE"},
+		{name: "running", status: "RUNNING", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var mu sync.Mutex
+			attempts := 0
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				io.ReadAll(r.Body)
+				mu.Lock()
+				attempts++
+				attempt := attempts
+				mu.Unlock()
+				if attempt == 1 {
+					w.Write([]byte(`{"Status":"Publish Timeout","Message":"publish timeout","NumberLoadedRows":1}`))
+					return
+				}
+				w.Write([]byte(`{"Status":"Label Already Exists","ExistingJobStatus":"` + tt.status + `","Message":"Label has already been used"}`))
+			}))
+			defer server.Close()
+
+			client := newTestClient(t, server)
+			client.SetRetryPolicy(RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond})
+
+			_, err := client.Load("users", strings.NewReader("1,a"), LoadOptions{Format: FormatCSV})
+			if tt.wantErr != (err != nil) {
+				t.Errorf("unexpected error %v", err)
+			}
+			if tt.wantErr && !errors.Is(err, ErrLabelAlreadyExists) {
+				t.Errorf("expected ErrLabelAlreadyExists, got %v", err)
+			}
+			if attempts != 2 {
+				t.Errorf("expected 2 attempts, got %d", attempts)
+			}
+		})
+	}
+
+	// A label already used before the first attempt is not this load's job
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"Status":"Label Already Exists","ExistingJobStatus":"FINISHED"}`))
+	}))
+	defer server.Close()
+	client := newTestClient(t, server)
+	client.SetRetryPolicy(RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond})
+	if _, err := client.Load("users", strings.NewReader("1,a"), LoadOptions{Label: "used"}); !errors.Is(err, ErrLabelAlreadyExists) {
+		t.Errorf("expected ErrLabelAlreadyExists, got %v", err)
+	}
+}
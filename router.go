@@ -0,0 +1,160 @@
+package streamload
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// RouterConfig configures a RoutingWriter
+type RouterConfig struct {
+	// Batch configures the batch of every table. MaxBufferedBytes and Concurrency
+	// are shared by all tables rather than applied to each of them.
+	Batch BatchConfig
+	// Tables holds the LoadOptions of each table
+	Tables map[string]LoadOptions
+	// DefaultOptions are used for tables missing from Tables, a Label is suffixed with the table name
+	DefaultOptions LoadOptions
+	// Route returns the destination table of a row written with WriteRouted
+	Route func(row []byte) (string, error)
+}
+
+// RoutingWriter fans rows out to one BatchWriter per destination table. The
+// tables are flushed independently but share a memory budget and a limit on
+// concurrent loads. It is safe for concurrent use.
+type RoutingWriter struct {
+	client *Client
+	config RouterConfig
+	budget *memoryBudget
+	sem    chan struct{}
+
+	mu      sync.Mutex
+	writers map[string]*BatchWriter
+	closed  bool
+}
+
+// NewRoutingWriter creates a writer that batches rows per destination table.
+// Call Close to flush the remaining rows and stop the writer.
+func (c *Client) NewRoutingWriter(config RouterConfig) *RoutingWriter {
+	config.Batch = config.Batch.withDefaults()
+	return &RoutingWriter{
+		client:  c,
+		config:  config,
+		budget:  newMemoryBudget(config.Batch.MaxBufferedBytes),
+		sem:     make(chan struct{}, config.Batch.Concurrency),
+		writers: make(map[string]*BatchWriter),
+	}
+}
+
+// Write adds an encoded row to the batch of table
+func (r *RoutingWriter) Write(table string, row []byte) error {
+	return r.WriteContext(context.Background(), table, row)
+}
+
+// WriteContext is like Write but stops waiting for memory when ctx is done
+func (r *RoutingWriter) WriteContext(ctx context.Context, table string, row []byte) error {
+	writer, err := r.writer(table)
+	if err != nil {
+		return err
+	}
+	return writer.WriteContext(ctx, row)
+}
+
+// WriteRouted adds an encoded row to the batch of the table returned by RouterConfig.Route
+func (r *RoutingWriter) WriteRouted(row []byte) error {
+	return r.WriteRoutedContext(context.Background(), row)
+}
+
+// WriteRoutedContext is like WriteRouted but stops waiting for memory when ctx is done
+func (r *RoutingWriter) WriteRoutedContext(ctx context.Context, row []byte) error {
+	if r.config.Route == nil {
+		return fmt.Errorf("no route function configured")
+	}
+	table, err := r.config.Route(row)
+	if err != nil {
+		return fmt.Errorf("failed to route row: %w", err)
+	}
+	return r.WriteContext(ctx, table, row)
+}
+
+// Flush loads the current batch of every table, waits for all pending loads and
+// returns the errors of the loads that failed since the previous Flush
+func (r *RoutingWriter) Flush() error {
+	var errs []error
+	for _, writer := range r.snapshot() {
+		errs = append(errs, writer.Flush())
+	}
+	return errors.Join(errs...)
+}
+
+// Close flushes the remaining rows of every table and stops the writer.
+// Writes after Close fail with ErrWriterClosed.
+func (r *RoutingWriter) Close() error {
+	r.mu.Lock()
+	r.closed = true
+	r.mu.Unlock()
+
+	var errs []error
+	for _, writer := range r.snapshot() {
+		errs = append(errs, writer.Close())
+	}
+	return errors.Join(errs...)
+}
+
+// Tables returns the tables written to so far
+func (r *RoutingWriter) Tables() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	tables := make([]string, 0, len(r.writers))
+	for table := range r.writers {
+		tables = append(tables, table)
+	}
+	sort.Strings(tables)
+	return tables
+}
+
+// writer returns the batch writer of table, creating it on first use
+func (r *RoutingWriter) writer(table string) (*BatchWriter, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed {
+		return nil, ErrWriterClosed
+	}
+
+	writer, ok := r.writers[table]
+	if !ok {
+		opts, ok := r.config.Tables[table]
+		if !ok {
+			opts = r.config.DefaultOptions
+			// Labels are unique per database, keep the tables sharing these options apart
+			if opts.Label != "" {
+				opts.Label += "-" + table
+			}
+		}
+		writer = r.client.newBatchWriter(table, opts, r.config.Batch, r.budget, r.sem)
+		// Any table may hold the shared budget, so load the batches of all of them
+		writer.pressure = r.dispatchAll
+		r.writers[table] = writer
+	}
+	return writer, nil
+}
+
+// snapshot returns the current batch writers
+func (r *RoutingWriter) snapshot() []*BatchWriter {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	writers := make([]*BatchWriter, 0, len(r.writers))
+	for _, writer := range r.writers {
+		writers = append(writers, writer)
+	}
+	return writers
+}
+
+// dispatchAll hands the current batch of every table to a background load
+func (r *RoutingWriter) dispatchAll() {
+	for _, writer := range r.snapshot() {
+		writer.dispatch()
+	}
+}
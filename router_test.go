@@ -0,0 +1,72 @@
+package streamload
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRoutingWriter(t *testing.T) {
+	var mu sync.Mutex
+	active, maxActive := 0, 0
+	loads := make(map[string]string)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		active++
+		if active > maxActive {
+			maxActive = active
+		}
+		mu.Unlock()
+
+		body, _ := io.ReadAll(r.Body)
+		time.Sleep(10 * time.Millisecond)
+
+		mu.Lock()
+		active--
+		table := strings.Split(r.URL.Path, "/")[3]
+		loads[table+" "+r.Header.Get("label")+" "+r.Header.Get("columns")] = string(body)
+		mu.Unlock()
+		w.Write([]byte(`{"Status":"Success"}`))
+	}))
+	defer server.Close()
+
+	client := newTestClient(t, server)
+	router := client.NewRoutingWriter(RouterConfig{
+		Batch:          BatchConfig{MaxRows: 2},
+		Tables:         map[string]LoadOptions{"users": {Columns: "id,name", Label: "u"}},
+		DefaultOptions: LoadOptions{Label: "d"},
+		Route: func(row []byte) (string, error) {
+			return strings.SplitN(string(row), ",", 2)[0], nil
+		},
+	})
+
+	for _, row := range []string{"users,1", "orders,1", "users,2", "orders,2", "events,1"} {
+		if err := router.WriteRouted([]byte(row)); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if err := router.Close(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := map[string]string{
+		"users u-1 id,name":  "users,1\nusers,2",
+		"orders d-orders-1 ": "orders,1\norders,2",
+		"events d-events-1 ": "events,1",
+	}
+	for key, body := range want {
+		if loads[key] != body {
+			t.Errorf("load %q: expected %q, got %q (all loads: %q)", key, body, loads[key], loads)
+		}
+	}
+	if maxActive != 1 {
+		t.Errorf("expected loads to share a single slot, got %d concurrent loads", maxActive)
+	}
+	if got := strings.Join(router.Tables(), ","); got != "events,orders,users" {
+		t.Errorf("unexpected tables %s", got)
+	}
+}
@@ -0,0 +1,204 @@
+package streamload
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// SkipRejectedConfig configures LoadSkipRejected
+type SkipRejectedConfig struct {
+	// MaxRounds is the maximum number of resubmissions, defaults to 3
+	MaxRounds int
+	// OnDropped is called with the records removed from the data, Value holds the raw record
+	// and Index its position in the original input. Rows filtered by a successful load are
+	// reported as well.
+	OnDropped func(dropped []RejectedElement)
+}
+
+// LoadSkipRejected loads data and, when the load fails because of rejected rows, removes
+// the rows listed in the error log and resubmits the remainder under a derived label
+// ("<label>-retry<n>"). The dropped records are handed to config.OnDropped.
+//
+// The data is buffered uncompressed in memory so it can be split into records: CSV is
+// split on opts.RowDelimiter, JSON is read as an array if it starts with '[' and as
+// newline-delimited objects otherwise. opts.Streaming is ignored.
+func (c *Client) LoadSkipRejected(ctx context.Context, table string, data io.Reader, opts LoadOptions, config SkipRejectedConfig) (*LoadResponse, error) {
+	if config.MaxRounds <= 0 {
+		config.MaxRounds = 3
+	}
+	if opts.Label == "" {
+		opts.Label = newLabel()
+	}
+	opts.FetchRejectedRows = true
+	opts.Streaming = false
+
+	raw, err := io.ReadAll(newContextReader(ctx, data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to buffer data: %w", err)
+	}
+	set, err := splitRecords(raw, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	baseLabel := opts.Label
+	var dropped int
+	for round := 0; ; round++ {
+		if round > 0 {
+			opts.Label = fmt.Sprintf("%s-retry%d", baseLabel, round)
+		}
+
+		resp, err := c.LoadContext(ctx, table, bytes.NewReader(set.join()), opts)
+		var loadErr *LoadError
+		if err != nil && (!errors.As(err, &loadErr) || len(loadErr.RejectedRows) == 0) {
+			return resp, err
+		}
+		if resp == nil || len(resp.RejectedRows) == 0 {
+			return resp, err
+		}
+
+		elements := set.match(resp.RejectedRows)
+		if len(elements) > 0 && config.OnDropped != nil {
+			config.OnDropped(elements)
+		}
+		if err == nil {
+			return resp, nil
+		}
+		if len(elements) == 0 {
+			return resp, fmt.Errorf("rejected rows could not be located in the data: %w", err)
+		}
+		if round >= config.MaxRounds {
+			return resp, fmt.Errorf("load still rejects rows after %d resubmissions: %w", round, err)
+		}
+
+		set.remove(elements)
+		dropped += len(elements)
+		if c.logger != nil {
+			c.logger.Printf("[DEBUG] LoadSkipRejected: dropped %d rows, resubmitting %d rows", len(elements), set.len())
+		}
+		if set.len() == 0 {
+			return &LoadResponse{
+				Label:              opts.Label,
+				Status:             "Success",
+				Message:            "all rows were rejected",
+				NumberFilteredRows: dropped,
+			}, nil
+		}
+	}
+}
+
+// recordSet holds the records of a load that have not been dropped
+type recordSet struct {
+	records   []string
+	origin    []int // Index of each record in the original input
+	delimiter string
+	jsonArray bool
+	isJSON    bool
+	separator string
+}
+
+// splitRecords splits data into the records StarRocks reports in its error log
+func splitRecords(data []byte, opts LoadOptions) (*recordSet, error) {
+	set := &recordSet{}
+	if opts.Format == FormatJSON {
+		set.isJSON = true
+		trimmed := bytes.TrimSpace(data)
+		if bytes.HasPrefix(trimmed, []byte("[")) {
+			var elements []json.RawMessage
+			if err := json.Unmarshal(trimmed, &elements); err != nil {
+				return nil, fmt.Errorf("failed to parse JSON array: %w", err)
+			}
+			set.jsonArray = true
+			for _, elem := range elements {
+				set.records = append(set.records, string(elem))
+			}
+		} else {
+			set.delimiter = "\n"
+			set.records = nonEmpty(strings.Split(string(data), "\n"))
+		}
+	} else {
+		set.delimiter = opts.RowDelimiter
+		if set.delimiter == "" {
+			set.delimiter = "\n"
+		}
+		set.separator = opts.ColumnSeparator
+		if set.separator == "" {
+			set.separator = "\t"
+		}
+		set.records = nonEmpty(strings.Split(string(data), set.delimiter))
+	}
+
+	set.origin = make([]int, len(set.records))
+	for i := range set.origin {
+		set.origin[i] = i
+	}
+	return set, nil
+}
+
+// nonEmpty drops empty records, such as the one after a trailing delimiter
+func nonEmpty(records []string) []string {
+	result := records[:0]
+	for _, record := range records {
+		if strings.TrimSpace(record) != "" {
+			result = append(result, record)
+		}
+	}
+	return result
+}
+
+func (s *recordSet) len() int { return len(s.records) }
+
+// join reassembles the remaining records
+func (s *recordSet) join() []byte {
+	if s.jsonArray {
+		return []byte("[" + strings.Join(s.records, ",") + "]")
+	}
+	return []byte(strings.Join(s.records, s.delimiter))
+}
+
+// match locates rejected rows among the remaining records. The returned indices
+// refer to the remaining records, remove translates them.
+func (s *recordSet) match(rows []RejectedRow) []RejectedElement {
+	index := newRowIndex()
+	normalize := strings.TrimSpace
+	if s.isJSON {
+		normalize = canonicalJSON
+	}
+	for i, record := range s.records {
+		if s.isJSON {
+			index.add(i, canonicalJSON(record))
+			continue
+		}
+		line := strings.TrimRight(record, "\r")
+		index.add(i, strings.TrimSpace(line), "["+strings.Join(strings.Split(line, s.separator), ", ")+"]")
+	}
+
+	elements := index.match(rows, normalize, func(i int) interface{} { return s.records[i] })
+	for i := range elements {
+		elements[i].Index = s.origin[elements[i].Index]
+	}
+	return elements
+}
+
+// remove drops the matched records
+func (s *recordSet) remove(elements []RejectedElement) {
+	drop := make(map[int]bool, len(elements))
+	for _, elem := range elements {
+		drop[elem.Index] = true
+	}
+
+	records, origin := s.records[:0], s.origin[:0]
+	for i, record := range s.records {
+		if drop[s.origin[i]] {
+			continue
+		}
+		records = append(records, record)
+		origin = append(origin, s.origin[i])
+	}
+	s.records, s.origin = records, origin
+}
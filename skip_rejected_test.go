@@ -0,0 +1,107 @@
+package streamload
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// strictServer fails every load containing a row starting with "bad", listing those rows in
+// the error log, and records the label and body of every load
+func strictServer(labels, bodies *[]string) *httptest.Server {
+	var mu sync.Mutex
+	var rejected []string
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if r.URL.Path == "/api/_load_error_log" {
+			for _, row := range rejected {
+				w.Write([]byte("Error: Value count does not match column count. Row: " + row + "\n"))
+			}
+			return
+		}
+
+		body, _ := io.ReadAll(r.Body)
+		*labels = append(*labels, r.Header.Get("label"))
+		*bodies = append(*bodies, string(body))
+		rejected = nil
+		for _, row := range strings.Split(string(body), "\n") {
+			if strings.HasPrefix(row, "bad") {
+				rejected = append(rejected, row)
+			}
+		}
+		if len(rejected) > 0 {
+			w.Write([]byte(`{"Status":"Fail","Message":"too many filtered rows","NumberFilteredRows":1,` +
+				`"ErrorURL":"` + server.URL + `/api/_load_error_log?file=error_log"}`))
+			return
+		}
+		w.Write([]byte(`{"Status":"Success","NumberLoadedRows":2}`))
+	}))
+	return server
+}
+
+func TestLoadSkipRejected(t *testing.T) {
+	var labels, bodies []string
+	server := strictServer(&labels, &bodies)
+	defer server.Close()
+
+	var dropped []RejectedElement
+	client := newTestClient(t, server)
+	resp, err := client.LoadSkipRejected(context.Background(), "users", strings.NewReader("1\tok\nbad1\n2\tok\nbad2\n"),
+		LoadOptions{Label: "heal", StrictMode: true}, SkipRejectedConfig{
+			OnDropped: func(elements []RejectedElement) { dropped = append(dropped, elements...) },
+		})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.NumberLoadedRows != 2 {
+		t.Errorf("expected 2 loaded rows, got %d", resp.NumberLoadedRows)
+	}
+	if len(labels) != 2 || labels[0] != "heal" || labels[1] != "heal-retry1" {
+		t.Errorf("unexpected labels %v", labels)
+	}
+	if bodies[1] != "1\tok\n2\tok" {
+		t.Errorf("unexpected resubmitted body %q", bodies[1])
+	}
+	if len(dropped) != 2 || dropped[0].Index != 1 || dropped[1].Index != 3 || dropped[1].Value != "bad2" {
+		t.Errorf("unexpected dropped rows %+v", dropped)
+	}
+}
+
+func TestLoadSkipRejected_JSONArray(t *testing.T) {
+	var mu sync.Mutex
+	var bodies []string
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if r.URL.Path == "/api/_load_error_log" {
+			w.Write([]byte(`Error: Value is out of range. Row: {"id": 2, "age": -1}` + "\n"))
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(body))
+		if strings.Contains(string(body), "-1") {
+			w.Write([]byte(`{"Status":"Fail","NumberFilteredRows":1,"ErrorURL":"` + server.URL + `/api/_load_error_log"}`))
+			return
+		}
+		w.Write([]byte(`{"Status":"Success","NumberLoadedRows":2}`))
+	}))
+	defer server.Close()
+
+	client := newTestClient(t, server)
+	_, err := client.LoadSkipRejected(context.Background(), "users",
+		strings.NewReader(`[{"id":1,"age":20},{"id":2,"age":-1},{"id":3,"age":30}]`),
+		LoadOptions{Format: FormatJSON, StripOuterArray: true}, SkipRejectedConfig{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(bodies) != 2 || bodies[1] != `[{"id":1,"age":20},{"id":3,"age":30}]` {
+		t.Errorf("unexpected bodies %q", bodies)
+	}
+}
@@ -0,0 +1,85 @@
+package streamload
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+)
+
+// SpoolConfig configures spooling of large buffered payloads to disk
+type SpoolConfig struct {
+	// Threshold is the payload size in bytes above which it is moved to a temp file, 0 disables spooling
+	Threshold int64
+	// Dir is the directory of the temp files, os.TempDir() is used when empty
+	Dir string
+}
+
+// SetSpoolConfig makes loads spool their (compressed) payload to a temp file once it
+// exceeds the threshold. The file is replayed for the redirect and for retries and
+// removed when the load finishes. Streaming loads are not affected.
+func (c *Client) SetSpoolConfig(config SpoolConfig) {
+	c.spool = config
+}
+
+// spoolBuffer keeps data in memory until it exceeds the threshold and then moves it to a temp file
+type spoolBuffer struct {
+	threshold int64
+	dir       string
+	mem       bytes.Buffer
+	file      *os.File
+	n         int64
+}
+
+func (b *spoolBuffer) Write(p []byte) (int, error) {
+	if b.file == nil && b.threshold > 0 && b.n+int64(len(p)) > b.threshold {
+		file, err := os.CreateTemp(b.dir, "streamload-spool-*")
+		if err != nil {
+			return 0, fmt.Errorf("failed to create spool file: %w", err)
+		}
+		b.file = file
+		if _, err := b.file.Write(b.mem.Bytes()); err != nil {
+			return 0, fmt.Errorf("failed to write spool file: %w", err)
+		}
+		b.mem = bytes.Buffer{}
+	}
+
+	var n int
+	var err error
+	if b.file != nil {
+		n, err = b.file.Write(p)
+	} else {
+		n, err = b.mem.Write(p)
+	}
+	b.n += int64(n)
+	return n, err
+}
+
+// payload returns the buffered data as a replayable payload
+func (b *spoolBuffer) payload() payload {
+	if b.file == nil {
+		return bytesPayload(b.mem.Bytes())
+	}
+	return &filePayload{sectionPayload: sectionPayload{r: b.file, n: b.n}, file: b.file}
+}
+
+// discard removes the spool file, if any
+func (b *spoolBuffer) discard() {
+	if b.file != nil {
+		b.file.Close()
+		os.Remove(b.file.Name())
+	}
+}
+
+// filePayload is a payload spooled to a temp file that is removed on close
+type filePayload struct {
+	sectionPayload
+	file *os.File
+}
+
+func (p *filePayload) close() error {
+	err := p.file.Close()
+	if removeErr := os.Remove(p.file.Name()); err == nil {
+		err = removeErr
+	}
+	return err
+}
@@ -0,0 +1,61 @@
+package streamload
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSpoolBuffer_StaysInMemoryBelowThreshold(t *testing.T) {
+	buf := &spoolBuffer{threshold: 10, dir: t.TempDir()}
+	buf.Write([]byte("12345"))
+	buf.Write([]byte("67890"))
+
+	if buf.file != nil {
+		t.Fatal("data at the threshold should stay in memory")
+	}
+	if _, ok := buf.payload().(bytesPayload); !ok {
+		t.Errorf("expected an in-memory payload, got %T", buf.payload())
+	}
+}
+
+func TestLoad_SpoolsLargePayloadAndReplaysIt(t *testing.T) {
+	var bodies []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(body))
+		if len(bodies) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			w.Write([]byte(`{"Status":"Fail","Message":"busy"}`))
+			return
+		}
+		w.Write([]byte(`{"Status":"Success"}`))
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	client := newTestClient(t, server)
+	client.SetSpoolConfig(SpoolConfig{Threshold: 16, Dir: dir})
+	client.SetRetryPolicy(RetryPolicy{MaxAttempts: 2, InitialBackoff: time.Millisecond})
+
+	data := strings.Repeat("1,Alice,25\n", 100)
+	if _, err := client.Load("users", strings.NewReader(data), LoadOptions{Format: FormatCSV}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(bodies) != 2 || bodies[0] != data || bodies[1] != data {
+		t.Fatalf("expected the spooled payload to be sent twice, got %d bodies", len(bodies))
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("failed to read spool dir: %v", err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("expected the spool file to be removed, found %d entries", len(entries))
+	}
+}
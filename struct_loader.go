@@ -2,6 +2,7 @@ package streamload
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"reflect"
@@ -25,11 +26,31 @@ var (
 // The structs parameter should be a slice of structs with csv tags
 // Example: []User where User has csv:"field_name" tags
 func (c *Client) LoadStructsCSV(table string, structs interface{}, opts LoadOptions) (*LoadResponse, error) {
+	return c.LoadStructsCSVContext(context.Background(), table, structs, opts)
+}
+
+// LoadStructsCSVContext is like LoadStructsCSV but honors ctx for cancellation
+func (c *Client) LoadStructsCSVContext(ctx context.Context, table string, structs interface{}, opts LoadOptions) (*LoadResponse, error) {
+	data, opts, err := encodeStructsCSV(structs, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	// Call the existing Load method
+	resp, err := c.LoadContext(ctx, table, bytes.NewReader(data), opts)
+	if resp != nil && len(resp.RejectedRows) > 0 {
+		attachRejectedElements(resp, err, matchRejectedCSV(structs, data, resp.RejectedRows))
+	}
+	return resp, err
+}
+
+// encodeStructsCSV encodes a slice of structs as CSV and applies the CSV defaults to opts
+func encodeStructsCSV(structs interface{}, opts LoadOptions) ([]byte, LoadOptions, error) {
 	// Extract column names from struct tags using reflection
 	if opts.Columns == "" {
 		columns, err := extractCSVColumns(structs)
 		if err != nil {
-			return nil, fmt.Errorf("failed to extract columns: %w", err)
+			return nil, opts, fmt.Errorf("failed to extract columns: %w", err)
 		}
 		opts.Columns = columns
 	}
@@ -37,7 +58,7 @@ func (c *Client) LoadStructsCSV(table string, structs interface{}, opts LoadOpti
 	// Convert structs to CSV using gocsv
 	var buf bytes.Buffer
 	if err := gocsv.MarshalWithoutHeaders(structs, &buf); err != nil {
-		return nil, fmt.Errorf("failed to marshal structs to CSV: %w", err)
+		return nil, opts, fmt.Errorf("failed to marshal structs to CSV: %w", err)
 	}
 
 	// Ensure Format is set to CSV
@@ -47,9 +68,7 @@ func (c *Client) LoadStructsCSV(table string, structs interface{}, opts LoadOpti
 	if opts.ColumnSeparator == "" {
 		opts.ColumnSeparator = ","
 	}
-
-	// Call the existing Load method
-	return c.Load(table, &buf, opts)
+	return buf.Bytes(), opts, nil
 }
 
 // LoadStructsJSON loads a slice of structs as JSON into StarRocks
@@ -57,11 +76,31 @@ func (c *Client) LoadStructsCSV(table string, structs interface{}, opts LoadOpti
 // Example: []User where User has json:"field_name" tags
 // By default, enables ZSTD compression and StripOuterArray
 func (c *Client) LoadStructsJSON(table string, structs interface{}, opts LoadOptions) (*LoadResponse, error) {
+	return c.LoadStructsJSONContext(context.Background(), table, structs, opts)
+}
+
+// LoadStructsJSONContext is like LoadStructsJSON but honors ctx for cancellation
+func (c *Client) LoadStructsJSONContext(ctx context.Context, table string, structs interface{}, opts LoadOptions) (*LoadResponse, error) {
+	jsonBytes, opts, err := encodeStructsJSON(structs, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	// Call the existing Load method
+	resp, err := c.LoadContext(ctx, table, bytes.NewReader(jsonBytes), opts)
+	if resp != nil && len(resp.RejectedRows) > 0 {
+		attachRejectedElements(resp, err, matchRejectedJSON(structs, resp.RejectedRows))
+	}
+	return resp, err
+}
+
+// encodeStructsJSON encodes a slice of structs as a JSON array and applies the JSON defaults to opts
+func encodeStructsJSON(structs interface{}, opts LoadOptions) ([]byte, LoadOptions, error) {
 	// Extract column names from struct tags using reflection
 	if opts.Columns == "" {
 		columns, err := extractJSONColumns(structs)
 		if err != nil {
-			return nil, fmt.Errorf("failed to extract columns: %w", err)
+			return nil, opts, fmt.Errorf("failed to extract columns: %w", err)
 		}
 		opts.Columns = columns
 	}
@@ -69,7 +108,7 @@ func (c *Client) LoadStructsJSON(table string, structs interface{}, opts LoadOpt
 	// Convert structs to JSON using encoding/json
 	jsonBytes, err := json.Marshal(structs)
 	if err != nil {
-		return nil, fmt.Errorf("failed to marshal structs to JSON: %w", err)
+		return nil, opts, fmt.Errorf("failed to marshal structs to JSON: %w", err)
 	}
 
 	// Set Format to JSON
@@ -82,9 +121,7 @@ func (c *Client) LoadStructsJSON(table string, structs interface{}, opts LoadOpt
 
 	// Enable StripOuterArray by default (required for JSON arrays)
 	opts.StripOuterArray = true
-
-	// Call the existing Load method
-	return c.Load(table, bytes.NewReader(jsonBytes), opts)
+	return jsonBytes, opts, nil
 }
 
 // extractCSVColumns extracts column names from struct csv tags using reflection
@@ -107,9 +144,11 @@ func extractCSVColumns(structs interface{}) (string, error) {
 		return "", fmt.Errorf("structs slice is empty, cannot extract columns")
 	}
 
-	// Get the type of the slice element
-	elemType := val.Type().Elem()
+	return csvColumnsOf(val.Type().Elem())
+}
 
+// csvColumnsOf extracts column names from the csv tags of a struct type or pointer to struct type
+func csvColumnsOf(elemType reflect.Type) (string, error) {
 	// If the element is a pointer, get the underlying type
 	if elemType.Kind() == reflect.Ptr {
 		elemType = elemType.Elem()
@@ -183,9 +222,11 @@ func extractJSONColumns(structs interface{}) (string, error) {
 		return "", fmt.Errorf("structs slice is empty, cannot extract columns")
 	}
 
-	// Get the type of the slice element
-	elemType := val.Type().Elem()
+	return jsonColumnsOf(val.Type().Elem())
+}
 
+// jsonColumnsOf extracts column names from the json tags of a struct type or pointer to struct type
+func jsonColumnsOf(elemType reflect.Type) (string, error) {
 	// If the element is a pointer, get the underlying type
 	if elemType.Kind() == reflect.Ptr {
 		elemType = elemType.Elem()
@@ -0,0 +1,91 @@
+package streamload
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"os"
+)
+
+// TLSOptions configures HTTPS connections to FE and BE endpoints
+type TLSOptions struct {
+	// CAFile is a PEM bundle used to verify server certificates, the system pool is used when empty
+	CAFile string
+	// CertFile and KeyFile hold the PEM client certificate and key for mutual TLS
+	CertFile string
+	KeyFile  string
+	// ServerName overrides the name used to verify server certificates,
+	// useful when FEs and BEs are reached through IPs or a shared certificate
+	ServerName string
+	// InsecureSkipVerify disables certificate verification, only use it for testing
+	InsecureSkipVerify bool
+}
+
+// SetTLS enables HTTPS for the client. FE endpoints without an explicit Scheme
+// switch to https, and redirects to HTTPS BE locations use the same TLS settings.
+// The TLS configuration is installed on the transport of the current HTTP client,
+// so call SetTLS after SetHTTPClient.
+func (c *Client) SetTLS(opts TLSOptions) error {
+	tlsConfig, err := buildTLSConfig(opts)
+	if err != nil {
+		return err
+	}
+
+	transport, err := c.cloneTransport()
+	if err != nil {
+		return err
+	}
+	transport.TLSClientConfig = tlsConfig
+
+	httpClient := *c.httpClient
+	httpClient.Transport = transport
+	c.httpClient = &httpClient
+	c.tlsEnabled = true
+	return nil
+}
+
+// cloneTransport returns a copy of the HTTP client's transport that can be modified safely
+func (c *Client) cloneTransport() (*http.Transport, error) {
+	switch t := c.httpClient.Transport.(type) {
+	case nil:
+		return http.DefaultTransport.(*http.Transport).Clone(), nil
+	case *http.Transport:
+		return t.Clone(), nil
+	default:
+		return nil, fmt.Errorf("unsupported transport type %T, configure it on the custom HTTP client instead", t)
+	}
+}
+
+// buildTLSConfig loads the certificates referenced by opts
+func buildTLSConfig(opts TLSOptions) (*tls.Config, error) {
+	config := &tls.Config{
+		ServerName:         opts.ServerName,
+		InsecureSkipVerify: opts.InsecureSkipVerify,
+	}
+
+	if opts.CAFile != "" {
+		caPEM, err := os.ReadFile(opts.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caPEM) {
+			return nil, fmt.Errorf("no certificates found in CA file %s", opts.CAFile)
+		}
+		config.RootCAs = pool
+	}
+
+	if opts.CertFile != "" || opts.KeyFile != "" {
+		if opts.CertFile == "" || opts.KeyFile == "" {
+			return nil, fmt.Errorf("both CertFile and KeyFile are required for a client certificate")
+		}
+		cert, err := tls.LoadX509KeyPair(opts.CertFile, opts.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %w", err)
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+
+	return config, nil
+}
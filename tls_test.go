@@ -0,0 +1,70 @@
+package streamload
+
+import (
+	"encoding/pem"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeCAFile stores the certificate of the test server as a PEM file
+func writeCAFile(t *testing.T, server *httptest.Server) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "ca.pem")
+	caPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	if err := os.WriteFile(path, caPEM, 0o600); err != nil {
+		t.Fatalf("failed to write CA file: %v", err)
+	}
+	return path
+}
+
+func TestSetTLS_RedirectToHTTPSBackend(t *testing.T) {
+	be := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"Status":"Success","NumberLoadedRows":1}`))
+	}))
+	defer be.Close()
+
+	fe := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, be.URL+r.URL.Path, http.StatusTemporaryRedirect)
+	}))
+	// Share the certificate so that a single CA bundle verifies both servers
+	fe.TLS = be.TLS
+	fe.StartTLS()
+	defer fe.Close()
+
+	host, port, _ := net.SplitHostPort(strings.TrimPrefix(fe.URL, "https://"))
+	client := NewClient(host, port, "test_db", "root", "")
+	if err := client.SetTLS(TLSOptions{CAFile: writeCAFile(t, be)}); err != nil {
+		t.Fatalf("failed to enable TLS: %v", err)
+	}
+
+	if !strings.HasPrefix(client.getCurrentFEURL(), "https://") {
+		t.Errorf("expected https FE URL, got %s", client.getCurrentFEURL())
+	}
+
+	resp, err := client.Load("users", strings.NewReader("1,a"), LoadOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.NumberLoadedRows != 1 {
+		t.Errorf("expected 1 loaded row, got %d", resp.NumberLoadedRows)
+	}
+}
+
+func TestSetTLS_InvalidOptions(t *testing.T) {
+	client := NewClient("localhost", "8030", "test_db", "root", "")
+
+	if err := client.SetTLS(TLSOptions{CAFile: filepath.Join(t.TempDir(), "missing.pem")}); err == nil {
+		t.Error("expected an error for a missing CA file")
+	}
+	if err := client.SetTLS(TLSOptions{CertFile: "client.pem"}); err == nil {
+		t.Error("expected an error for a certificate without key")
+	}
+	if client.tlsEnabled {
+		t.Error("TLS should stay disabled after a failed SetTLS")
+	}
+}
@@ -1,441 +1,270 @@
 package streamload
 
 import (
-	"bytes"
-	"encoding/json"
+	"context"
+	"errors"
 	"fmt"
 	"io"
-	"net/http"
+	"math"
+	"strconv"
 	"strings"
+	"time"
 )
 
-// BeginTransaction begins a new transaction with the specified label
-func (c *Client) BeginTransaction(label string, tables []string) (*TransactionBeginResponse, error) {
-	urlStr := fmt.Sprintf("%s/api/transaction/begin", c.getCurrentFEURL())
+// ErrTableNotInTransaction is returned when loading into a table the transaction was not begun on
+var ErrTableNotInTransaction = errors.New("table is not part of the transaction")
 
-	// Always use table as string (StarRocks expects string, not array element)
-	tableValue := tables[0]
+// txnTables holds the declared tables of a transaction and the statistics of their loads
+type txnTables struct {
+	stats   map[string]*TableLoadStats
+	expires time.Time // When the server aborts the transaction at the latest, zero if unknown
+}
 
-	req, err := http.NewRequest("POST", urlStr, nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
-	}
+// BeginTransaction begins a new transaction with the specified label on tables.
+// Loads into other tables through this client are rejected with ErrTableNotInTransaction.
+func (c *Client) BeginTransaction(label string, tables []string) (*TransactionBeginResponse, error) {
+	return c.BeginTransactionContext(context.Background(), label, tables)
+}
 
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Expect", "100-continue")
-	req.Header.Set("label", label)
-	req.Header.Set("db", c.database)
-	req.Header.Set("table", tableValue)
-	req.SetBasicAuth(c.username, c.password)
+// BeginTransactionContext is like BeginTransaction but honors ctx for cancellation
+func (c *Client) BeginTransactionContext(ctx context.Context, label string, tables []string) (*TransactionBeginResponse, error) {
+	return c.BeginTransactionWithOptions(ctx, label, tables, TransactionOptions{})
+}
 
-	if c.logger != nil {
-		c.logger.Printf("[DEBUG] BeginTransaction Headers: %+v", req.Header)
+// BeginTransactionWithOptions is like BeginTransactionContext but sends the timeouts
+// and headers of opts. The watchdog fields of opts only apply to Begin.
+func (c *Client) BeginTransactionWithOptions(ctx context.Context, label string, tables []string, opts TransactionOptions) (*TransactionBeginResponse, error) {
+	if len(tables) == 0 {
+		return nil, errors.New("begin transaction requires at least one table")
 	}
 
-	resp, err := c.doRequest(req)
-	if err != nil {
-		return nil, fmt.Errorf("failed to send request: %w", err)
+	// StarRocks expects the tables as a single comma-separated string
+	headers := c.transactionHeaders(label)
+	headers["table"] = strings.Join(tables, ",")
+	if opts.Timeout > 0 {
+		headers["timeout"] = seconds(opts.Timeout)
 	}
-
-	// Handle 307 Temporary Redirect from FE to BE
-	if resp.StatusCode == http.StatusTemporaryRedirect {
-		location := resp.Header.Get("Location")
-		if location == "" {
-			resp.Body.Close()
-			return nil, fmt.Errorf("received 307 redirect without Location header")
-		}
-
-		resp.Body.Close()
-
-		redirectReq, err := http.NewRequest("POST", location, nil)
-		if err != nil {
-			return nil, fmt.Errorf("failed to create redirect request: %w", err)
-		}
-
-		redirectReq.Header.Set("Content-Type", "application/json")
-		redirectReq.Header.Set("Expect", "100-continue")
-		redirectReq.Header.Set("label", label)
-		redirectReq.Header.Set("db", c.database)
-		redirectReq.Header.Set("table", tableValue)
-		redirectReq.SetBasicAuth(c.username, c.password)
-
-		resp, err = c.httpClient.Do(redirectReq)
-		if err != nil {
-			return nil, fmt.Errorf("failed to send redirect request: %w", err)
-		}
+	if opts.IdleTimeout > 0 {
+		headers["idle_transaction_timeout"] = seconds(opts.IdleTimeout)
 	}
-	defer resp.Body.Close()
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read response body: %w", err)
+	for key, value := range opts.Headers {
+		headers[key] = value
 	}
 
-	var txnResp TransactionBeginResponse
-	if err := json.Unmarshal(body, &txnResp); err != nil {
-		return nil, fmt.Errorf("failed to parse response: %w", err)
+	if c.logger != nil {
+		c.logger.Printf("[DEBUG] BeginTransaction Headers: %+v", headers)
 	}
 
-	if resp.StatusCode != http.StatusOK {
-		return &txnResp, fmt.Errorf("begin transaction failed with status %d: %s", resp.StatusCode, txnResp.Message)
+	if c.journal != nil {
+		// Journal first, a crash right after the begin must not leave an unknown transaction
+		entry := JournalEntry{Label: label, Tables: tables, Phase: JournalBegun}
+		if err := c.journal.record(entry); err != nil {
+			return nil, err
+		}
 	}
 
-	return &txnResp, nil
+	resp, err := call[TransactionBeginResponse](c, ctx, "begin transaction", "POST", "/api/transaction/begin", headers, nil, "OK")
+	if err != nil {
+		// An existing label may belong to an earlier transaction that is still journaled
+		if !isAmbiguousLoadError(err) && !errors.Is(err, ErrLabelAlreadyExists) {
+			c.journalDone(label)
+		}
+		return resp, err
+	}
+	c.trackTransaction(label, tables, opts.Timeout)
+	c.journalPhase(label, JournalBegun, resp.TxnId)
+	return resp, nil
 }
 
 // PrepareTransaction pre-commits the current transaction
 // Note: This should be called after loading data with LoadTransaction
 func (c *Client) PrepareTransaction(label string) (*TransactionPrepareResponse, error) {
-	urlStr := fmt.Sprintf("%s/api/transaction/prepare", c.getCurrentFEURL())
-
-	req, err := http.NewRequest("POST", urlStr, nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
-	}
-
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Expect", "100-continue")
-	req.Header.Set("label", label)
-	req.Header.Set("db", c.database)
-	req.SetBasicAuth(c.username, c.password)
-
-	resp, err := c.doRequest(req)
-	if err != nil {
-		return nil, fmt.Errorf("failed to send request: %w", err)
-	}
-
-	// Handle 307 Temporary Redirect from FE to BE
-	if resp.StatusCode == http.StatusTemporaryRedirect {
-		location := resp.Header.Get("Location")
-		if location == "" {
-			resp.Body.Close()
-			return nil, fmt.Errorf("received 307 redirect without Location header")
-		}
-
-		resp.Body.Close()
-
-		redirectReq, err := http.NewRequest("POST", location, nil)
-		if err != nil {
-			return nil, fmt.Errorf("failed to create redirect request: %w", err)
-		}
-
-		redirectReq.Header.Set("Content-Type", "application/json")
-		redirectReq.Header.Set("Expect", "100-continue")
-		redirectReq.Header.Set("label", label)
-		redirectReq.Header.Set("db", c.database)
-		redirectReq.SetBasicAuth(c.username, c.password)
-
-		resp, err = c.httpClient.Do(redirectReq)
-		if err != nil {
-			return nil, fmt.Errorf("failed to send redirect request: %w", err)
-		}
-	}
-	defer resp.Body.Close()
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read response body: %w", err)
-	}
-
-	var prepResp TransactionPrepareResponse
-	if err := json.Unmarshal(body, &prepResp); err != nil {
-		return nil, fmt.Errorf("failed to parse response: %w", err)
-	}
-
-	if resp.StatusCode != http.StatusOK {
-		return &prepResp, fmt.Errorf("prepare transaction failed with status %d: %s", resp.StatusCode, prepResp.Message)
-	}
+	return c.PrepareTransactionContext(context.Background(), label)
+}
 
-	if prepResp.Status != "OK" {
-		return &prepResp, fmt.Errorf("prepare transaction failed: %s", prepResp.Message)
+// PrepareTransactionContext is like PrepareTransaction but honors ctx for cancellation
+func (c *Client) PrepareTransactionContext(ctx context.Context, label string) (*TransactionPrepareResponse, error) {
+	headers := c.transactionHeaders(label)
+	resp, err := call[TransactionPrepareResponse](c, ctx, "prepare transaction", "POST", "/api/transaction/prepare", headers, nil, "OK")
+	if err == nil {
+		resp.Tables = c.transactionStats(label)
+		c.journalPhase(label, JournalPrepared, resp.TxnId)
 	}
-
-	return &prepResp, nil
+	c.forgetIfGone(label, err)
+	return resp, err
 }
 
 // LoadTransaction loads data into a transaction with specified label
 func (c *Client) LoadTransaction(label, table string, data io.Reader, opts LoadOptions) (*LoadResponse, error) {
-	urlStr := fmt.Sprintf("%s/api/transaction/load", c.getCurrentFEURL())
+	return c.LoadTransactionContext(context.Background(), label, table, data, opts)
+}
 
-	// Compress data into buffer to support retry on redirect
-	var dataBuf bytes.Buffer
-	var err error
-	if opts.Compression != CompressionNone {
-		compressedReader, err := c.compressData(data, opts.Compression)
-		if err != nil {
-			return nil, fmt.Errorf("failed to compress data: %w", err)
-		}
-		if _, err := io.Copy(&dataBuf, compressedReader); err != nil {
-			return nil, fmt.Errorf("failed to buffer compressed data: %w", err)
-		}
-	} else {
-		if _, err := io.Copy(&dataBuf, data); err != nil {
-			return nil, fmt.Errorf("failed to buffer data: %w", err)
-		}
+// LoadTransactionContext is like LoadTransaction but honors ctx for cancellation
+// of compression, the FE request and the redirected BE request
+func (c *Client) LoadTransactionContext(ctx context.Context, label, table string, data io.Reader, opts LoadOptions) (*LoadResponse, error) {
+	if err := c.checkTransactionTable(label, table); err != nil {
+		return nil, err
 	}
 
-	// Use bytes.NewReader to support seeking for retries
-	reader := bytes.NewReader(dataBuf.Bytes())
-
-	if c.logger != nil {
-		c.logger.Printf("[DEBUG] LoadTransaction: Data size = %d bytes", dataBuf.Len())
+	body, err := c.newPayload(ctx, data, opts)
+	if err != nil {
+		return nil, err
 	}
+	defer body.close()
 
-	headers := make(map[string]string)
-	for k, v := range c.defaultHeader {
-		headers[k] = v
+	if c.logger != nil && body.size() >= 0 {
+		c.logger.Printf("[DEBUG] LoadTransaction: Data size = %d bytes", body.size())
 	}
-	headers["Expect"] = "100-continue"
-	headers["strip_outer_array"] = fmt.Sprintf("%t", opts.StripOuterArray)
+
+	headers := c.loadHeaders(opts)
 	headers["label"] = label
 	headers["db"] = c.database
 	headers["table"] = table
 
-	if opts.TimeoutStr != "" {
-		headers["timeout"] = opts.TimeoutStr
-	}
-
-	if opts.Compression != CompressionNone {
-		headers["compression"] = string(opts.Compression)
-	}
-
-	if opts.Format != "" {
-		headers["format"] = string(opts.Format)
-	}
-	if opts.Columns != "" {
-		headers["columns"] = opts.Columns
-	}
-	if opts.ColumnSeparator != "" {
-		headers["column_separator"] = opts.ColumnSeparator
-	}
-	if opts.RowDelimiter != "" {
-		headers["row_delimiter"] = opts.RowDelimiter
-	}
-	if opts.Where != "" {
-		headers["where"] = opts.Where
-	}
-	if opts.MaxFilterRatio != "" {
-		headers["max_filter_ratio"] = opts.MaxFilterRatio
-	}
-	if len(opts.Partitions) > 0 {
-		headers["partitions"] = strings.Join(opts.Partitions, ",")
-	}
-	if len(opts.TemporaryPartitions) > 0 {
-		headers["temporary_partitions"] = strings.Join(opts.TemporaryPartitions, ",")
-	}
-	if opts.LogRejectedRecordNum != 0 {
-		headers["log_rejected_record_num"] = fmt.Sprintf("%d", opts.LogRejectedRecordNum)
-	}
-	if opts.Timezone != "" {
-		headers["timezone"] = opts.Timezone
-	}
-	if opts.LoadMemLimit > 0 {
-		headers["load_mem_limit"] = fmt.Sprintf("%d", opts.LoadMemLimit)
-	}
-	if opts.StrictMode {
-		headers["strict_mode"] = "true"
-	}
-
-	req, err := http.NewRequest("PUT", urlStr, reader)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
-	}
-
-	req.SetBasicAuth(c.username, c.password)
-	for k, v := range headers {
-		req.Header.Set(k, v)
-	}
-
-	resp, err := c.doRequest(req)
-	if err != nil {
-		return nil, fmt.Errorf("failed to send request: %w", err)
+	resp, err := call[LoadResponse](c, ctx, "transaction load", "PUT", "/api/transaction/load", headers, body, "OK")
+	c.attachRejectedRows(ctx, resp, err, opts)
+	if err == nil {
+		c.recordTransactionLoad(label, table, resp)
 	}
-
-	// Handle 307 Temporary Redirect from FE to BE
-	if resp.StatusCode == http.StatusTemporaryRedirect {
-		location := resp.Header.Get("Location")
-		if location == "" {
-			resp.Body.Close()
-			return nil, fmt.Errorf("received 307 redirect without Location header")
-		}
-
-		resp.Body.Close()
-
-		// Reset reader position for retry
-		reader.Seek(0, io.SeekStart)
-
-		redirectReq, err := http.NewRequest("PUT", location, reader)
-		if err != nil {
-			return nil, fmt.Errorf("failed to create redirect request: %w", err)
-		}
-
-		redirectReq.SetBasicAuth(c.username, c.password)
-		for k, v := range headers {
-			redirectReq.Header.Set(k, v)
-		}
-
-		resp, err = c.httpClient.Do(redirectReq)
-		if err != nil {
-			return nil, fmt.Errorf("failed to send redirect request: %w", err)
-		}
-	}
-	defer resp.Body.Close()
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read response body: %w", err)
-	}
-
-	if c.logger != nil {
-		c.logger.Printf("[DEBUG] LoadTransaction: Response body = %s", string(body))
-	}
-
-	var loadResp LoadResponse
-	if err := json.Unmarshal(body, &loadResp); err != nil {
-		return nil, fmt.Errorf("failed to parse response: %w", err)
-	}
-
-	if resp.StatusCode != http.StatusOK {
-		return &loadResp, fmt.Errorf("transaction load failed with status %d: %s", resp.StatusCode, loadResp.Message)
-	}
-
-	if loadResp.Status != "OK" {
-		return &loadResp, fmt.Errorf("transaction load failed: %s", loadResp.Message)
-	}
-
-	return &loadResp, nil
+	c.forgetIfGone(label, err)
+	return resp, err
 }
 
 // CommitTransaction commits the transaction with the specified label
 func (c *Client) CommitTransaction(label string) (*TransactionCommitResponse, error) {
-	urlStr := fmt.Sprintf("%s/api/transaction/commit", c.getCurrentFEURL())
+	return c.CommitTransactionContext(context.Background(), label)
+}
 
-	req, err := http.NewRequest("POST", urlStr, nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
-	}
+// CommitTransactionContext is like CommitTransaction but honors ctx for cancellation
+func (c *Client) CommitTransactionContext(ctx context.Context, label string) (*TransactionCommitResponse, error) {
+	headers := c.transactionHeaders(label)
+	resp, err := call[TransactionCommitResponse](c, ctx, "commit transaction", "POST", "/api/transaction/commit", headers, nil, "OK")
+	if err == nil {
+		resp.Tables = c.transactionStats(label)
+		c.forgetTransaction(label)
+		c.journalDone(label)
+	}
+	c.forgetIfGone(label, err)
+	return resp, err
+}
 
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Expect", "100-continue")
-	req.Header.Set("label", label)
-	req.Header.Set("db", c.database)
-	req.SetBasicAuth(c.username, c.password)
+// RollbackTransaction rolls back the transaction with the specified label
+func (c *Client) RollbackTransaction(label string) (*TransactionRollbackResponse, error) {
+	return c.RollbackTransactionContext(context.Background(), label)
+}
 
-	resp, err := c.doRequest(req)
-	if err != nil {
-		return nil, fmt.Errorf("failed to send request: %w", err)
+// RollbackTransactionContext is like RollbackTransaction but honors ctx for cancellation
+func (c *Client) RollbackTransactionContext(ctx context.Context, label string) (*TransactionRollbackResponse, error) {
+	headers := c.transactionHeaders(label)
+	resp, err := call[TransactionRollbackResponse](c, ctx, "rollback transaction", "POST", "/api/transaction/rollback", headers, nil, "OK")
+	if err == nil {
+		c.forgetTransaction(label)
+		c.journalDone(label)
 	}
+	c.forgetIfGone(label, err)
+	return resp, err
+}
 
-	// Handle 307 Temporary Redirect from FE to BE
-	if resp.StatusCode == http.StatusTemporaryRedirect {
-		location := resp.Header.Get("Location")
-		if location == "" {
-			resp.Body.Close()
-			return nil, fmt.Errorf("received 307 redirect without Location header")
-		}
-
-		resp.Body.Close()
-
-		redirectReq, err := http.NewRequest("POST", location, nil)
-		if err != nil {
-			return nil, fmt.Errorf("failed to create redirect request: %w", err)
-		}
-
-		redirectReq.Header.Set("Content-Type", "application/json")
-		redirectReq.Header.Set("Expect", "100-continue")
-		redirectReq.Header.Set("label", label)
-		redirectReq.Header.Set("db", c.database)
-		redirectReq.SetBasicAuth(c.username, c.password)
-
-		resp, err = c.httpClient.Do(redirectReq)
-		if err != nil {
-			return nil, fmt.Errorf("failed to send redirect request: %w", err)
-		}
+// transactionHeaders builds the headers shared by the transaction control endpoints
+func (c *Client) transactionHeaders(label string) map[string]string {
+	return map[string]string{
+		"Content-Type": "application/json",
+		"Expect":       "100-continue",
+		"label":        label,
+		"db":           c.database,
 	}
-	defer resp.Body.Close()
+}
 
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read response body: %w", err)
+// trackTransaction starts tracking the tables of a begun transaction. Transactions
+// past their timeout are dropped, the server has aborted them.
+func (c *Client) trackTransaction(label string, tables []string, timeout time.Duration) {
+	txn := &txnTables{stats: make(map[string]*TableLoadStats, len(tables))}
+	for _, table := range tables {
+		txn.stats[table] = &TableLoadStats{}
 	}
-
-	if c.logger != nil {
-		c.logger.Printf("[DEBUG] CommitTransaction: Response body = %s", string(body))
+	now := time.Now()
+	if timeout > 0 {
+		txn.expires = now.Add(timeout)
 	}
 
-	var commitResp TransactionCommitResponse
-	if err := json.Unmarshal(body, &commitResp); err != nil {
-		return nil, fmt.Errorf("failed to parse response: %w", err)
+	c.txnsMu.Lock()
+	defer c.txnsMu.Unlock()
+	if c.txns == nil {
+		c.txns = make(map[string]*txnTables)
 	}
-
-	if resp.StatusCode != http.StatusOK {
-		return &commitResp, fmt.Errorf("commit transaction failed with status %d: %s", resp.StatusCode, commitResp.Message)
+	for other, t := range c.txns {
+		if !t.expires.IsZero() && now.After(t.expires) {
+			delete(c.txns, other)
+		}
 	}
-
-	return &commitResp, nil
+	c.txns[label] = txn
 }
 
-// RollbackTransaction rolls back the transaction with the specified label
-func (c *Client) RollbackTransaction(label string) (*TransactionRollbackResponse, error) {
-	urlStr := fmt.Sprintf("%s/api/transaction/rollback", c.getCurrentFEURL())
-
-	req, err := http.NewRequest("POST", urlStr, nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+// checkTransactionTable fails if a transaction begun by this client was not begun on table.
+// Transactions begun elsewhere are not checked.
+func (c *Client) checkTransactionTable(label, table string) error {
+	c.txnsMu.Lock()
+	defer c.txnsMu.Unlock()
+	txn, ok := c.txns[label]
+	if !ok {
+		return nil
 	}
-
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Expect", "100-continue")
-	req.Header.Set("label", label)
-	req.Header.Set("db", c.database)
-	req.SetBasicAuth(c.username, c.password)
-
-	resp, err := c.doRequest(req)
-	if err != nil {
-		return nil, fmt.Errorf("failed to send request: %w", err)
+	if _, ok := txn.stats[table]; !ok {
+		return fmt.Errorf("%w: %s is not one of the tables of transaction %s", ErrTableNotInTransaction, table, label)
 	}
+	return nil
+}
 
-	// Handle 307 Temporary Redirect from FE to BE
-	if resp.StatusCode == http.StatusTemporaryRedirect {
-		location := resp.Header.Get("Location")
-		if location == "" {
-			resp.Body.Close()
-			return nil, fmt.Errorf("received 307 redirect without Location header")
-		}
-
-		resp.Body.Close()
-
-		redirectReq, err := http.NewRequest("POST", location, nil)
-		if err != nil {
-			return nil, fmt.Errorf("failed to create redirect request: %w", err)
-		}
-
-		redirectReq.Header.Set("Content-Type", "application/json")
-		redirectReq.Header.Set("Expect", "100-continue")
-		redirectReq.Header.Set("label", label)
-		redirectReq.Header.Set("db", c.database)
-		redirectReq.SetBasicAuth(c.username, c.password)
+// recordTransactionLoad adds the statistics of a load to its table
+func (c *Client) recordTransactionLoad(label, table string, resp *LoadResponse) {
+	c.txnsMu.Lock()
+	defer c.txnsMu.Unlock()
+	txn, ok := c.txns[label]
+	if !ok {
+		return
+	}
+	stats := txn.stats[table]
+	stats.Loads++
+	stats.NumberTotalRows += resp.NumberTotalRows
+	stats.NumberLoadedRows += resp.NumberLoadedRows
+	stats.NumberFilteredRows += resp.NumberFilteredRows
+	stats.NumberUnselectedRows += resp.NumberUnselectedRows
+	stats.LoadBytes += resp.LoadBytes
+}
 
-		resp, err = c.httpClient.Do(redirectReq)
-		if err != nil {
-			return nil, fmt.Errorf("failed to send redirect request: %w", err)
-		}
+// transactionStats returns a copy of the per-table statistics of a transaction
+func (c *Client) transactionStats(label string) map[string]TableLoadStats {
+	c.txnsMu.Lock()
+	defer c.txnsMu.Unlock()
+	txn, ok := c.txns[label]
+	if !ok {
+		return nil
 	}
-	defer resp.Body.Close()
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read response body: %w", err)
+	stats := make(map[string]TableLoadStats, len(txn.stats))
+	for table, s := range txn.stats {
+		stats[table] = *s
 	}
+	return stats
+}
 
-	var rollbackResp TransactionRollbackResponse
-	if err := json.Unmarshal(body, &rollbackResp); err != nil {
-		return nil, fmt.Errorf("failed to parse response: %w", err)
+// forgetIfGone stops tracking a transaction once err shows that it no longer exists
+// or was aborted, so it can neither be committed nor rolled back anymore
+func (c *Client) forgetIfGone(label string, err error) {
+	var loadErr *LoadError
+	if errors.Is(err, ErrTxnNotFound) ||
+		(errors.As(err, &loadErr) && strings.Contains(strings.ToLower(loadErr.Message), "aborted")) {
+		c.forgetTransaction(label)
 	}
+}
 
-	if resp.StatusCode != http.StatusOK {
-		return &rollbackResp, fmt.Errorf("rollback transaction failed with status %d: %s", resp.StatusCode, rollbackResp.Message)
-	}
+// forgetTransaction stops tracking a committed or rolled back transaction
+func (c *Client) forgetTransaction(label string) {
+	c.txnsMu.Lock()
+	defer c.txnsMu.Unlock()
+	delete(c.txns, label)
+}
 
-	return &rollbackResp, nil
+// seconds formats d as whole seconds, rounding up so a timeout never shrinks
+func seconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
 }
@@ -0,0 +1,307 @@
+package streamload
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// ErrTransactionState is returned when an operation is not allowed in the current state of a Transaction
+var ErrTransactionState = errors.New("invalid transaction state")
+
+// TransactionState is the client-side state of a Transaction
+type TransactionState string
+
+const (
+	TxnActive     TransactionState = "ACTIVE"      // Begun, data can be loaded
+	TxnPrepared   TransactionState = "PREPARED"    // Prepared, waits for commit or rollback
+	TxnCommitted  TransactionState = "COMMITTED"   // Committed
+	TxnRolledBack TransactionState = "ROLLED_BACK" // Rolled back
+)
+
+// Transaction is a two-phase commit transaction started with Begin. It tracks
+// its state, so loads after Prepare or a second Commit fail with ErrTransactionState
+// instead of reaching StarRocks. Operations are serialized, a Transaction is safe
+// for concurrent use.
+//
+// If TransactionOptions.IdleTimeout is set, a watchdog warns, and optionally rolls
+// the transaction back, when no data was loaded for almost IdleTimeout.
+type Transaction struct {
+	client *Client
+	label  string
+	tables []string
+	opts   TransactionOptions
+
+	mu           sync.Mutex
+	state        TransactionState
+	txnID        int64
+	lastActivity time.Time
+	watchdog     *time.Timer
+}
+
+// Begin begins a transaction on tables. A label is generated if label is empty.
+func (c *Client) Begin(ctx context.Context, label string, tables []string) (*Transaction, error) {
+	return c.BeginWithOptions(ctx, label, tables, TransactionOptions{})
+}
+
+// BeginWithOptions is like Begin but applies opts, see TransactionOptions. It fails
+// without contacting StarRocks if opts.IdleMargin is not shorter than opts.IdleTimeout.
+func (c *Client) BeginWithOptions(ctx context.Context, label string, tables []string, opts TransactionOptions) (*Transaction, error) {
+	if opts.IdleTimeout > 0 && opts.IdleMargin >= opts.IdleTimeout {
+		// The watchdog would fire, and possibly roll back, right after begin
+		return nil, fmt.Errorf("idle margin %v must be shorter than the idle timeout %v", opts.IdleMargin, opts.IdleTimeout)
+	}
+	if label == "" {
+		label = newLabel()
+	}
+	resp, err := c.BeginTransactionWithOptions(ctx, label, tables, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	tx := &Transaction{
+		client:       c,
+		label:        label,
+		tables:       append([]string(nil), tables...),
+		opts:         opts,
+		state:        TxnActive,
+		txnID:        resp.TxnId,
+		lastActivity: time.Now(),
+	}
+	if opts.IdleTimeout > 0 {
+		if tx.opts.IdleMargin <= 0 {
+			tx.opts.IdleMargin = opts.IdleTimeout / 10
+		}
+		tx.watchdog = time.AfterFunc(opts.IdleTimeout-tx.opts.IdleMargin, tx.checkIdle)
+	}
+	return tx, nil
+}
+
+// Label returns the label of the transaction
+func (tx *Transaction) Label() string {
+	return tx.label
+}
+
+// TxnId returns the transaction id assigned by StarRocks
+func (tx *Transaction) TxnId() int64 {
+	return tx.txnID
+}
+
+// Tables returns the tables the transaction was begun on
+func (tx *Transaction) Tables() []string {
+	return append([]string(nil), tx.tables...)
+}
+
+// State returns the current state of the transaction
+func (tx *Transaction) State() TransactionState {
+	tx.mu.Lock()
+	defer tx.mu.Unlock()
+	return tx.state
+}
+
+// expect fails unless the transaction is in one of the given states, tx.mu must be held
+func (tx *Transaction) expect(op string, states ...TransactionState) error {
+	for _, state := range states {
+		if tx.state == state {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: cannot %s transaction %s in state %s", ErrTransactionState, op, tx.label, tx.state)
+}
+
+// Load loads data into table as part of the transaction
+func (tx *Transaction) Load(ctx context.Context, table string, data io.Reader, opts LoadOptions) (*LoadResponse, error) {
+	tx.mu.Lock()
+	defer tx.mu.Unlock()
+	if err := tx.expect("load into", TxnActive); err != nil {
+		return nil, err
+	}
+	resp, err := tx.client.LoadTransactionContext(ctx, tx.label, table, data, opts)
+	tx.touch()
+	return resp, err
+}
+
+// LoadStructs loads a slice of structs into table as part of the transaction. The
+// structs are encoded like LoadStructsCSV does if opts.Format is FormatCSV, and like
+// LoadStructsJSON does otherwise.
+func (tx *Transaction) LoadStructs(ctx context.Context, table string, structs interface{}, opts LoadOptions) (*LoadResponse, error) {
+	csv := opts.Format == FormatCSV
+	var data []byte
+	var err error
+	if csv {
+		data, opts, err = encodeStructsCSV(structs, opts)
+	} else {
+		data, opts, err = encodeStructsJSON(structs, opts)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := tx.Load(ctx, table, bytes.NewReader(data), opts)
+	if resp != nil && len(resp.RejectedRows) > 0 {
+		if csv {
+			attachRejectedElements(resp, err, matchRejectedCSV(structs, data, resp.RejectedRows))
+		} else {
+			attachRejectedElements(resp, err, matchRejectedJSON(structs, resp.RejectedRows))
+		}
+	}
+	return resp, err
+}
+
+// Prepare pre-commits the transaction. Preparing a prepared transaction is a no-op.
+func (tx *Transaction) Prepare(ctx context.Context) (*TransactionPrepareResponse, error) {
+	tx.mu.Lock()
+	defer tx.mu.Unlock()
+	if tx.state == TxnPrepared {
+		return &TransactionPrepareResponse{TxnId: tx.txnID, Status: "OK"}, nil
+	}
+	if err := tx.expect("prepare", TxnActive); err != nil {
+		return nil, err
+	}
+
+	resp, err := tx.client.PrepareTransactionContext(ctx, tx.label)
+	if err == nil {
+		tx.state = TxnPrepared
+		// The idle timeout only applies while data is loaded
+		tx.stopWatchdog()
+	}
+	return resp, err
+}
+
+// Commit commits the transaction, preparing it first if needed. The transaction is
+// COMMITTED only once StarRocks accepted the commit; if the commit fails or is
+// rejected, the transaction stays prepared, so Commit may be retried.
+func (tx *Transaction) Commit(ctx context.Context) (*TransactionCommitResponse, error) {
+	if _, err := tx.Prepare(ctx); err != nil {
+		return nil, err
+	}
+
+	tx.mu.Lock()
+	defer tx.mu.Unlock()
+	if err := tx.expect("commit", TxnPrepared); err != nil {
+		return nil, err
+	}
+
+	resp, err := tx.client.CommitTransactionContext(ctx, tx.label)
+	if err == nil {
+		tx.state = TxnCommitted
+	}
+	return resp, err
+}
+
+// Rollback rolls back the transaction. Rolling back a rolled back transaction is a no-op.
+func (tx *Transaction) Rollback(ctx context.Context) (*TransactionRollbackResponse, error) {
+	tx.mu.Lock()
+	defer tx.mu.Unlock()
+	return tx.rollbackLocked(ctx)
+}
+
+// rollbackLocked rolls back the transaction, tx.mu must be held
+func (tx *Transaction) rollbackLocked(ctx context.Context) (*TransactionRollbackResponse, error) {
+	if tx.state == TxnRolledBack {
+		return &TransactionRollbackResponse{TxnId: tx.txnID, Status: "OK"}, nil
+	}
+	if err := tx.expect("roll back", TxnActive, TxnPrepared); err != nil {
+		return nil, err
+	}
+
+	resp, err := tx.client.RollbackTransactionContext(ctx, tx.label)
+	if err == nil {
+		tx.state = TxnRolledBack
+		tx.stopWatchdog()
+	}
+	return resp, err
+}
+
+// touch records activity on the transaction and re-arms the watchdog, tx.mu must be held
+func (tx *Transaction) touch() {
+	tx.lastActivity = time.Now()
+	if tx.watchdog != nil {
+		tx.watchdog.Reset(tx.opts.IdleTimeout - tx.opts.IdleMargin)
+	}
+}
+
+// stopWatchdog stops the watchdog once the transaction is no longer loading, tx.mu must be held
+func (tx *Transaction) stopWatchdog() {
+	if tx.watchdog != nil {
+		tx.watchdog.Stop()
+	}
+}
+
+// checkIdle is run by the watchdog shortly before the server-side idle timeout expires
+func (tx *Transaction) checkIdle() {
+	tx.mu.Lock()
+	last := tx.lastActivity
+	remaining := tx.opts.IdleTimeout - time.Since(last)
+	if tx.state != TxnActive || remaining > tx.opts.IdleMargin {
+		// Raced with a load or with the end of the transaction
+		tx.mu.Unlock()
+		return
+	}
+	tx.mu.Unlock()
+
+	// Outside the lock, OnIdle may load more data to keep the transaction alive
+	if tx.opts.OnIdle != nil {
+		tx.opts.OnIdle(tx, remaining)
+	} else if tx.client.logger != nil {
+		tx.client.logger.Printf("[DEBUG] Transaction %s has been idle for %v and will be aborted by the server in %v",
+			tx.label, time.Since(last).Round(time.Millisecond), remaining.Round(time.Millisecond))
+	}
+	if !tx.opts.RollbackOnIdle {
+		return
+	}
+
+	tx.mu.Lock()
+	defer tx.mu.Unlock()
+	if tx.state != TxnActive || !tx.lastActivity.Equal(last) {
+		return
+	}
+	if _, err := tx.rollbackLocked(context.Background()); err != nil && tx.client.logger != nil {
+		tx.client.logger.Printf("[DEBUG] Rollback of idle transaction %s failed: %v", tx.label, err)
+	}
+}
+
+// RunInTransaction begins a transaction and calls fn with it. The transaction is
+// committed if fn returns nil and rolled back if fn returns an error or panics;
+// a panic is re-raised after the rollback. The returned error joins the error of
+// fn with the error of the rollback, if any. fn must not commit or roll back tx.
+func (c *Client) RunInTransaction(ctx context.Context, label string, tables []string, fn func(tx *Transaction) error) error {
+	tx, err := c.Begin(ctx, label, tables)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			tx.rollbackAfter(ctx, fmt.Errorf("panic: %v", p))
+			panic(p)
+		}
+	}()
+
+	if err := fn(tx); err != nil {
+		return tx.rollbackAfter(ctx, err)
+	}
+	if _, err := tx.Commit(ctx); err != nil {
+		return tx.rollbackAfter(ctx, err)
+	}
+	return nil
+}
+
+// rollbackAfter rolls back the transaction after cause and returns cause joined with the rollback error
+func (tx *Transaction) rollbackAfter(ctx context.Context, cause error) error {
+	// Roll back even if ctx is what made fn fail
+	if ctx.Err() != nil {
+		ctx = context.WithoutCancel(ctx)
+	}
+	if _, err := tx.Rollback(ctx); err != nil {
+		if tx.client.logger != nil {
+			tx.client.logger.Printf("[DEBUG] Rollback of transaction %s after %v failed: %v", tx.label, cause, err)
+		}
+		return errors.Join(cause, fmt.Errorf("rollback failed: %w", err))
+	}
+	return cause
+}
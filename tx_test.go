@@ -0,0 +1,354 @@
+package streamload
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// transactionServer answers the transaction endpoints and records the calls as
+// "<endpoint> <table>"; loads whose body contains "bad" fail, and so do commits of
+// labels starting with "reject"
+func transactionServer(calls *[]string, mu *sync.Mutex) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		endpoint := strings.TrimPrefix(r.URL.Path, "/api/transaction/")
+		mu.Lock()
+		*calls = append(*calls, strings.TrimSpace(endpoint+" "+r.Header.Get("table")))
+		mu.Unlock()
+
+		switch {
+		case endpoint == "load" && strings.Contains(string(body), "bad"):
+			w.Write([]byte(`{"TxnId":7,"Status":"Fail","Message":"too many filtered rows"}`))
+		case endpoint == "commit" && strings.HasPrefix(r.Header.Get("label"), "reject"):
+			w.Write([]byte(`{"TxnId":7,"Status":"FAILED","Message":"publish failed"}`))
+		case endpoint == "load" || endpoint == "prepare":
+			w.Write([]byte(`{"TxnId":7,"Status":"OK","NumberLoadedRows":1}`))
+		default:
+			w.Write([]byte(`{"TxnId":7,"Status":"OK"}`))
+		}
+	}))
+}
+
+func TestTransaction(t *testing.T) {
+	var mu sync.Mutex
+	var calls []string
+	server := transactionServer(&calls, &mu)
+	defer server.Close()
+
+	ctx := context.Background()
+	client := newTestClient(t, server)
+	tx, err := client.Begin(ctx, "txn-1", []string{"users"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if tx.TxnId() != 7 || tx.State() != TxnActive {
+		t.Fatalf("unexpected transaction %d in state %s", tx.TxnId(), tx.State())
+	}
+
+	if _, err := tx.LoadStructs(ctx, "users", []TestUser{{Id: 1, Name: "Alice", Age: 25}}, LoadOptions{Format: FormatCSV}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := tx.Commit(ctx); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if tx.State() != TxnCommitted {
+		t.Errorf("expected COMMITTED, got %s", tx.State())
+	}
+
+	if _, err := tx.Load(ctx, "users", strings.NewReader("2,Bob,30"), LoadOptions{}); !errors.Is(err, ErrTransactionState) {
+		t.Errorf("expected ErrTransactionState, got %v", err)
+	}
+	if _, err := tx.Rollback(ctx); !errors.Is(err, ErrTransactionState) {
+		t.Errorf("expected ErrTransactionState, got %v", err)
+	}
+	want := []string{"begin users", "load users", "prepare", "commit"}
+	if strings.Join(calls, ",") != strings.Join(want, ",") {
+		t.Errorf("expected calls %v, got %v", want, calls)
+	}
+}
+
+func TestTransaction_RejectedCommit(t *testing.T) {
+	var mu sync.Mutex
+	var calls []string
+	server := transactionServer(&calls, &mu)
+	defer server.Close()
+
+	ctx := context.Background()
+	client := newTestClient(t, server)
+	tx, err := client.Begin(ctx, "reject-1", []string{"users"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var loadErr *LoadError
+	if _, err := tx.Commit(ctx); !errors.As(err, &loadErr) || loadErr.Status != "FAILED" {
+		t.Errorf("expected the rejected commit to fail, got %v", err)
+	}
+	if tx.State() != TxnPrepared {
+		t.Errorf("expected the transaction to stay PREPARED, got %s", tx.State())
+	}
+}
+
+func TestRunInTransaction(t *testing.T) {
+	tests := []struct {
+		name    string
+		label   string
+		fn      func(tx *Transaction) error
+		wantErr bool
+		want    []string
+	}{
+		{
+			name: "commit",
+			fn: func(tx *Transaction) error {
+				_, err := tx.Load(context.Background(), "users", strings.NewReader("1,a"), LoadOptions{})
+				return err
+			},
+			want: []string{"begin users", "load users", "prepare", "commit"},
+		},
+		{
+			name: "rollback on error",
+			fn: func(tx *Transaction) error {
+				_, err := tx.Load(context.Background(), "users", strings.NewReader("bad"), LoadOptions{})
+				return err
+			},
+			wantErr: true,
+			want:    []string{"begin users", "load users", "rollback"},
+		},
+		{
+			name:  "rollback on rejected commit",
+			label: "reject-2",
+			fn: func(tx *Transaction) error {
+				_, err := tx.Load(context.Background(), "users", strings.NewReader("1,a"), LoadOptions{})
+				return err
+			},
+			wantErr: true,
+			want:    []string{"begin users", "load users", "prepare", "commit", "rollback"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var mu sync.Mutex
+			var calls []string
+			server := transactionServer(&calls, &mu)
+			defer server.Close()
+
+			client := newTestClient(t, server)
+			err := client.RunInTransaction(context.Background(), tt.label, []string{"users"}, tt.fn)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if strings.Join(calls, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("expected calls %v, got %v", tt.want, calls)
+			}
+		})
+	}
+}
+
+func TestRunInTransaction_Panic(t *testing.T) {
+	var mu sync.Mutex
+	var calls []string
+	server := transactionServer(&calls, &mu)
+	defer server.Close()
+
+	client := newTestClient(t, server)
+	defer func() {
+		if p := recover(); p != "boom" {
+			t.Errorf("expected the panic to be re-raised, got %v", p)
+		}
+		if len(calls) != 2 || calls[1] != "rollback" {
+			t.Errorf("expected a rollback, got %v", calls)
+		}
+	}()
+	client.RunInTransaction(context.Background(), "", []string{"users"}, func(tx *Transaction) error {
+		panic("boom")
+	})
+}
+
+func TestBeginTransaction_MultipleTables(t *testing.T) {
+	var mu sync.Mutex
+	var calls []string
+	server := transactionServer(&calls, &mu)
+	defer server.Close()
+
+	client := newTestClient(t, server)
+	if _, err := client.BeginTransaction("txn-0", nil); err == nil {
+		t.Error("expected an error for a transaction without tables")
+	}
+
+	ctx := context.Background()
+	tx, err := client.Begin(ctx, "txn-1", []string{"orders", "customers"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := tx.Load(ctx, "products", strings.NewReader("1,a"), LoadOptions{}); !errors.Is(err, ErrTableNotInTransaction) {
+		t.Errorf("expected ErrTableNotInTransaction, got %v", err)
+	}
+	for _, table := range []string{"orders", "orders", "customers"} {
+		if _, err := tx.Load(ctx, table, strings.NewReader("1,a"), LoadOptions{}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	prepared, err := tx.Prepare(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if prepared.Tables["orders"].Loads != 2 || prepared.Tables["orders"].NumberLoadedRows != 2 {
+		t.Errorf("unexpected prepare statistics %+v", prepared.Tables)
+	}
+	committed, err := tx.Commit(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(committed.Tables) != 2 || committed.Tables["customers"].NumberLoadedRows != 1 {
+		t.Errorf("unexpected commit statistics %+v", committed.Tables)
+	}
+
+	want := []string{"begin orders,customers", "load orders", "load orders", "load customers", "prepare", "commit"}
+	if strings.Join(calls, ";") != strings.Join(want, ";") {
+		t.Errorf("expected calls %v, got %v", want, calls)
+	}
+}
+
+func TestBeginWithOptions(t *testing.T) {
+	headers := make(chan http.Header, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/transaction/begin" {
+			headers <- r.Header.Clone()
+		}
+		w.Write([]byte(`{"TxnId":7,"Status":"OK"}`))
+	}))
+	defer server.Close()
+
+	client := newTestClient(t, server)
+	tx, err := client.BeginWithOptions(context.Background(), "txn-1", []string{"users"}, TransactionOptions{
+		Timeout:     10 * time.Minute,
+		IdleTimeout: 1500 * time.Millisecond,
+		Headers:     map[string]string{"warehouse": "etl"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer tx.Rollback(context.Background())
+
+	h := <-headers
+	if h.Get("timeout") != "600" || h.Get("idle_transaction_timeout") != "2" || h.Get("warehouse") != "etl" {
+		t.Errorf("unexpected begin headers %v", h)
+	}
+}
+
+func TestBeginWithOptions_InvalidIdleMargin(t *testing.T) {
+	var mu sync.Mutex
+	var calls []string
+	server := transactionServer(&calls, &mu)
+	defer server.Close()
+
+	client := newTestClient(t, server)
+	for _, margin := range []time.Duration{time.Second, 2 * time.Second} {
+		_, err := client.BeginWithOptions(context.Background(), "txn-1", []string{"users"}, TransactionOptions{
+			IdleTimeout:    time.Second,
+			IdleMargin:     margin,
+			RollbackOnIdle: true,
+		})
+		if err == nil {
+			t.Errorf("expected an error for margin %v", margin)
+		}
+	}
+	if len(calls) != 0 {
+		t.Errorf("expected no requests, got %v", calls)
+	}
+}
+
+func TestTransaction_IdleWatchdog(t *testing.T) {
+	var mu sync.Mutex
+	var calls []string
+	server := transactionServer(&calls, &mu)
+	defer server.Close()
+
+	idle := make(chan time.Duration, 1)
+	client := newTestClient(t, server)
+	ctx := context.Background()
+	tx, err := client.BeginWithOptions(ctx, "txn-1", []string{"users"}, TransactionOptions{
+		IdleTimeout:    300 * time.Millisecond,
+		IdleMargin:     100 * time.Millisecond,
+		OnIdle:         func(tx *Transaction, remaining time.Duration) { idle <- remaining },
+		RollbackOnIdle: true,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	time.Sleep(100 * time.Millisecond)
+	if _, err := tx.Load(ctx, "users", strings.NewReader("1,a"), LoadOptions{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	select {
+	case remaining := <-idle:
+		if remaining > 100*time.Millisecond {
+			t.Errorf("watchdog fired %v before the idle timeout", remaining)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("watchdog did not fire")
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for tx.State() != TxnRolledBack {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected the idle transaction to be rolled back, got %s", tx.State())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if _, err := tx.Load(ctx, "users", strings.NewReader("2,b"), LoadOptions{}); !errors.Is(err, ErrTransactionState) {
+		t.Errorf("expected ErrTransactionState after the idle rollback, got %v", err)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if strings.Join(calls, ",") != "begin users,load users,rollback" {
+		t.Errorf("unexpected calls %v", calls)
+	}
+}
+
+func TestTransaction_ForgetsGoneTransactions(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/api/transaction/begin" && r.Header.Get("label") == "rejected":
+			w.Write([]byte(`{"Status":"FAILED","Message":"too many running transactions"}`))
+		case r.URL.Path == "/api/transaction/begin":
+			w.Write([]byte(`{"TxnId":7,"Status":"OK"}`))
+		case r.Header.Get("label") == "aborted":
+			w.Write([]byte(`{"Status":"FAILED","Message":"transaction 7 has been aborted: timeout"}`))
+		default:
+			w.Write([]byte(`{"Status":"FAILED","Message":"transaction with label missing not found"}`))
+		}
+	}))
+	defer server.Close()
+
+	client := newTestClient(t, server)
+	if _, err := client.BeginTransaction("rejected", []string{"users"}); err == nil {
+		t.Error("expected the rejected begin to fail")
+	}
+	client.BeginTransaction("missing", []string{"users"})
+	client.BeginTransaction("aborted", []string{"users"})
+	if _, err := client.CommitTransaction("missing"); !errors.Is(err, ErrTxnNotFound) {
+		t.Errorf("expected ErrTxnNotFound, got %v", err)
+	}
+	if _, err := client.LoadTransaction("aborted", "users", strings.NewReader("1,a"), LoadOptions{}); err == nil {
+		t.Error("expected the load into the aborted transaction to fail")
+	}
+
+	client.BeginTransactionWithOptions(context.Background(), "expired", []string{"users"}, TransactionOptions{Timeout: time.Nanosecond})
+	time.Sleep(time.Millisecond)
+	client.BeginTransaction("live", []string{"users"})
+
+	client.txnsMu.Lock()
+	defer client.txnsMu.Unlock()
+	if len(client.txns) != 1 || client.txns["live"] == nil {
+		t.Errorf("expected only the live transaction to be tracked, got %v", client.txns)
+	}
+}
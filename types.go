@@ -4,8 +4,9 @@ import "time"
 
 // FEEndpoint represents a StarRocks FE endpoint
 type FEEndpoint struct {
-	Host string
-	Port string
+	Host   string
+	Port   string
+	Scheme string // "http" or "https", defaults to https after SetTLS and http otherwise
 }
 
 // CompressionType represents the compression algorithm
@@ -49,11 +50,48 @@ type LoadOptions struct {
 	LogRejectedRecordNum int
 	Timezone             string
 	LoadMemLimit         int64
+
+	// Streaming sends the data straight onto the wire instead of buffering it in memory.
+	// The data is only sent to the FE once it answered "Expect: 100-continue" with
+	// "100 Continue", so its 307 is followed before any data is read, however long the
+	// FE takes and whatever the transport's ExpectContinueTimeout. A server that waits
+	// for the body without answering 100 Continue blocks the load until it times out.
+	// A streamed load cannot be retried once its data was sent.
+	Streaming bool
+
+	// FetchRejectedRows downloads and parses the error log when rows were filtered,
+	// attaching them to LoadResponse.RejectedRows and to a returned *LoadError
+	FetchRejectedRows bool
+}
+
+// TransactionOptions represents options for beginning a transaction
+type TransactionOptions struct {
+	// Timeout bounds the lifetime of the transaction, sent as "timeout" in seconds
+	Timeout time.Duration
+	// IdleTimeout aborts the transaction on the server once no data was loaded for this
+	// long, sent as "idle_transaction_timeout" in seconds
+	IdleTimeout time.Duration
+	// Headers are added to the begin request as they are
+	Headers map[string]string
+
+	// IdleMargin is how long before IdleTimeout expires the watchdog of a Transaction
+	// acts, defaults to a tenth of IdleTimeout and must be shorter than IdleTimeout. The
+	// watchdog only runs if IdleTimeout is set.
+	IdleMargin time.Duration
+	// OnIdle is called by the watchdog with the time left until the server aborts the
+	// transaction. The warning is logged if OnIdle is nil.
+	OnIdle func(tx *Transaction, remaining time.Duration)
+	// RollbackOnIdle rolls the transaction back when the watchdog fires, releasing its
+	// resources before the server-side timeout
+	RollbackOnIdle bool
 }
 
 // LoadResponse represents the response from StarRocks
 type LoadResponse struct {
+	TxnId                     int64  `json:"TxnId"`
+	Label                     string `json:"Label"`
 	Status                    string `json:"Status"`
+	ExistingJobStatus         string `json:"ExistingJobStatus"`
 	Message                   string `json:"Message"`
 	NumberTotalRows           int    `json:"NumberTotalRows"`
 	NumberLoadedRows          int    `json:"NumberLoadedRows"`
@@ -68,6 +106,11 @@ type LoadResponse struct {
 	CommittedAndPublishTimeMs int    `json:"CommittedAndPublishTimeMs"`
 	ErrorURL                  string `json:"ErrorURL"`
 	Timezone                  string `json:"Timezone"`
+
+	// RejectedRows holds the parsed error log if LoadOptions.FetchRejectedRows was set
+	RejectedRows []RejectedRow `json:"-"`
+	// RejectedElements maps RejectedRows to the input slice of LoadStructsCSV and LoadStructsJSON
+	RejectedElements []RejectedElement `json:"-"`
 }
 
 // TransactionBeginResponse represents the response for beginning a transaction
@@ -92,6 +135,10 @@ type TransactionPrepareResponse struct {
 	ReceivedDataTimeMs     int    `json:"ReceivedDataTimeMs"`
 	WriteDataTimeMs        int    `json:"WriteDataTimeMs"`
 	CommitAndPublishTimeMs int    `json:"CommitAndPublishTimeMs"`
+
+	// Tables holds the statistics of the loads into each table of the transaction
+	// made through this client, nil if the transaction was begun elsewhere
+	Tables map[string]TableLoadStats `json:"-"`
 }
 
 // TransactionCommitResponse represents the response for committing a transaction
@@ -109,6 +156,20 @@ type TransactionCommitResponse struct {
 	ReceivedDataTimeMs     int    `json:"ReceivedDataTimeMs"`
 	WriteDataTimeMs        int    `json:"WriteDataTimeMs"`
 	CommitAndPublishTimeMs int    `json:"CommitAndPublishTimeMs"`
+
+	// Tables holds the statistics of the loads into each table of the transaction
+	// made through this client, nil if the transaction was begun elsewhere
+	Tables map[string]TableLoadStats `json:"-"`
+}
+
+// TableLoadStats sums the loads into one table of a transaction
+type TableLoadStats struct {
+	Loads                int
+	NumberTotalRows      int
+	NumberLoadedRows     int
+	NumberFilteredRows   int
+	NumberUnselectedRows int
+	LoadBytes            int
 }
 
 // TransactionRollbackResponse represents the response for rolling back a transaction
@@ -117,3 +178,23 @@ type TransactionRollbackResponse struct {
 	Status  string `json:"Status"`
 	Message string `json:"Message"`
 }
+
+func (r *LoadResponse) info() responseInfo {
+	return responseInfo{Status: r.Status, Message: r.Message, TxnId: r.TxnId, ErrorURL: r.ErrorURL, ExistingJobStatus: r.ExistingJobStatus}
+}
+
+func (r *TransactionBeginResponse) info() responseInfo {
+	return responseInfo{Status: r.Status, Message: r.Message, TxnId: r.TxnId}
+}
+
+func (r *TransactionPrepareResponse) info() responseInfo {
+	return responseInfo{Status: r.Status, Message: r.Message, TxnId: r.TxnId}
+}
+
+func (r *TransactionCommitResponse) info() responseInfo {
+	return responseInfo{Status: r.Status, Message: r.Message, TxnId: r.TxnId}
+}
+
+func (r *TransactionRollbackResponse) info() responseInfo {
+	return responseInfo{Status: r.Status, Message: r.Message, TxnId: r.TxnId}
+}
@@ -0,0 +1,328 @@
+package streamload
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// walVersion is the version of the segment file format
+const walVersion = 1
+
+// walRecordHeaderSize is the size of the length and checksum preceding every record
+const walRecordHeaderSize = 8
+
+var walChecksum = crc32.MakeTable(crc32.Castagnoli)
+
+// WALConfig configures a write-ahead log
+type WALConfig struct {
+	// Sync fsyncs every row before Write returns. Without it, acknowledged rows
+	// survive a crash of the process but not of the operating system.
+	Sync bool
+}
+
+// WAL is a write-ahead log of batched rows. Set BatchConfig.WAL to append every
+// row to a segment file before Write returns; each batch is one segment and is
+// loaded under a label derived from the segment id. A segment is deleted once
+// its batch is loaded, so the segments left after a crash are exactly the rows
+// that may not have been loaded. Call ReplayWAL on startup to load them.
+//
+// A segment file <id>.wal is a sequence of records, each a 4-byte big-endian
+// length, a 4-byte big-endian CRC-32C of the data and the data. The first record
+// is the JSON segment header (version, table, label and options), every further
+// record is one row. A truncated or corrupt record ends the segment; it can only
+// be a row whose Write did not return.
+type WAL struct {
+	path     string
+	config   WALConfig
+	instance string // Distinguishes the segment ids of WALs opened at the same time
+
+	mu     sync.Mutex
+	lastID int64
+	open   map[string]bool // Segments written or loaded by this process
+}
+
+// walHeader is the first record of a segment
+type walHeader struct {
+	Version int         `json:"version"`
+	Table   string      `json:"table"`
+	Label   string      `json:"label"`
+	Options LoadOptions `json:"options"`
+}
+
+// walSegment is a segment being written
+type walSegment struct {
+	wal   *WAL
+	id    string
+	label string
+	file  *os.File
+}
+
+// OpenWAL opens the write-ahead log in the directory at path, creating it if needed
+func OpenWAL(path string, config WALConfig) (*WAL, error) {
+	if err := os.MkdirAll(path, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create WAL directory: %w", err)
+	}
+	return &WAL{
+		path:     path,
+		config:   config,
+		instance: uuid.NewString()[:8],
+		open:     make(map[string]bool),
+	}, nil
+}
+
+// Path returns the directory of the segment files
+func (l *WAL) Path() string {
+	return l.path
+}
+
+// Segments returns the ids of the segments not in use by this process, oldest first
+func (l *WAL) Segments() ([]string, error) {
+	names, err := filepath.Glob(filepath.Join(l.path, "*.wal"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(names)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	ids := make([]string, 0, len(names))
+	for _, name := range names {
+		id := strings.TrimSuffix(filepath.Base(name), ".wal")
+		if !l.open[id] {
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+// create starts a new segment for a batch of table. The segment label is opts.Label,
+// or "wal" if empty, followed by the segment id.
+func (l *WAL) create(table string, opts LoadOptions) (*walSegment, error) {
+	l.mu.Lock()
+	// Ids are zero-padded nanoseconds, so they sort by creation time
+	id := time.Now().UnixNano()
+	if id <= l.lastID {
+		id = l.lastID + 1
+	}
+	l.lastID = id
+	seg := &walSegment{wal: l, id: fmt.Sprintf("%020d-%s", id, l.instance)}
+	l.open[seg.id] = true
+	l.mu.Unlock()
+
+	prefix := opts.Label
+	if prefix == "" {
+		prefix = "wal"
+	}
+	seg.label = prefix + "-" + seg.id
+	opts.Label = seg.label
+	opts.Streaming = false
+	opts.FetchRejectedRows = false
+
+	header, err := json.Marshal(walHeader{Version: walVersion, Table: table, Label: seg.label, Options: opts})
+	if err == nil {
+		seg.file, err = os.OpenFile(filepath.Join(l.path, seg.id+".wal"), os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o644)
+	}
+	if err == nil {
+		err = seg.append(header)
+	}
+	if err != nil {
+		seg.remove()
+		return nil, fmt.Errorf("failed to create WAL segment: %w", err)
+	}
+	return seg, nil
+}
+
+// append writes a record to the segment
+func (s *walSegment) append(data []byte) error {
+	record := make([]byte, walRecordHeaderSize+len(data))
+	binary.BigEndian.PutUint32(record, uint32(len(data)))
+	binary.BigEndian.PutUint32(record[4:], crc32.Checksum(data, walChecksum))
+	copy(record[walRecordHeaderSize:], data)
+
+	if _, err := s.file.Write(record); err != nil {
+		return fmt.Errorf("failed to append to WAL segment: %w", err)
+	}
+	if s.wal.config.Sync {
+		if err := s.file.Sync(); err != nil {
+			return fmt.Errorf("failed to sync WAL segment: %w", err)
+		}
+	}
+	return nil
+}
+
+// seal closes the segment file once its batch is complete
+func (s *walSegment) seal() error {
+	if s.file == nil {
+		return nil
+	}
+	err := s.file.Sync()
+	if closeErr := s.file.Close(); err == nil {
+		err = closeErr
+	}
+	s.file = nil
+	return err
+}
+
+// remove deletes the segment after its batch was loaded or abandoned
+func (s *walSegment) remove() error {
+	s.seal()
+	err := os.Remove(filepath.Join(s.wal.path, s.id+".wal"))
+	if errors.Is(err, os.ErrNotExist) {
+		err = nil
+	}
+	s.release()
+	return err
+}
+
+// release hands a segment that failed to load over to ReplayWAL
+func (s *walSegment) release() {
+	s.wal.mu.Lock()
+	defer s.wal.mu.Unlock()
+	delete(s.wal.open, s.id)
+}
+
+// readSegment reads the header and rows of a segment, stopping at the first damaged record
+func (l *WAL) readSegment(id string) (walHeader, [][]byte, error) {
+	var header walHeader
+	data, err := os.ReadFile(filepath.Join(l.path, id+".wal"))
+	if err != nil {
+		return header, nil, fmt.Errorf("failed to read WAL segment: %w", err)
+	}
+
+	var records [][]byte
+	for len(data) >= walRecordHeaderSize {
+		n := binary.BigEndian.Uint32(data)
+		if uint64(n) > uint64(len(data)-walRecordHeaderSize) {
+			break
+		}
+		record := data[walRecordHeaderSize : walRecordHeaderSize+int(n)]
+		if crc32.Checksum(record, walChecksum) != binary.BigEndian.Uint32(data[4:]) {
+			break
+		}
+		records = append(records, record)
+		data = data[walRecordHeaderSize+int(n):]
+	}
+
+	if len(records) == 0 {
+		// Crashed while creating the segment, it holds no acknowledged rows
+		return header, nil, nil
+	}
+	if err := json.Unmarshal(records[0], &header); err != nil {
+		return header, nil, fmt.Errorf("failed to parse WAL segment header: %w", err)
+	}
+	if header.Version != walVersion {
+		return header, nil, fmt.Errorf("unsupported WAL segment version %d", header.Version)
+	}
+	return header, records[1:], nil
+}
+
+// ReplayWAL loads the segments left behind by a previous process, oldest first, and
+// deletes them once loaded. A segment whose label is already VISIBLE or COMMITTED is
+// deleted without loading it again; other segments are loaded with LoadOnce under
+// their label. Segments that fail are kept and reported in their result; the error is
+// only set if the directory cannot be read or ctx is done.
+//
+// Call ReplayWAL before writing to the WAL, or concurrently with writers of this
+// process, whose segments are left alone.
+func (c *Client) ReplayWAL(ctx context.Context, wal *WAL) ([]FlushResult, error) {
+	ids, err := wal.Segments()
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]FlushResult, 0, len(ids))
+	for _, id := range ids {
+		if err := ctx.Err(); err != nil {
+			return results, err
+		}
+		result, ok := c.replaySegment(ctx, wal, id)
+		if !ok {
+			continue
+		}
+		if c.logger != nil {
+			c.logger.Printf("[DEBUG] Replayed WAL segment %s into %s: %v", id, result.Table, result.Err)
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// replaySegment loads a single segment. ok is false if the segment holds no rows,
+// or was claimed by a writer in the meantime.
+func (c *Client) replaySegment(ctx context.Context, wal *WAL, id string) (result FlushResult, ok bool) {
+	wal.mu.Lock()
+	if wal.open[id] {
+		wal.mu.Unlock()
+		return result, false
+	}
+	wal.open[id] = true
+	wal.mu.Unlock()
+	seg := &walSegment{wal: wal, id: id}
+	defer seg.release()
+
+	start := time.Now()
+	header, rows, err := wal.readSegment(id)
+	if err == nil && len(rows) == 0 {
+		seg.remove()
+		return result, false
+	}
+	result = FlushResult{Table: header.Table, Label: header.Label, Rows: len(rows)}
+	if err != nil {
+		result.Err = fmt.Errorf("WAL segment %s: %w", id, err)
+		return result, true
+	}
+
+	state, err := c.GetLoadStateContext(ctx, header.Label)
+	switch {
+	case err != nil:
+		result.Err = fmt.Errorf("failed to look up label %s of WAL segment %s: %w", header.Label, id, err)
+	case state == LoadStateVisible || state == LoadStateCommitted:
+		// Loaded before the crash, only the deletion was lost
+	case state == LoadStateUnknown || state == LoadStateAborted:
+		payload := joinRows(rows, header.Options)
+		result.Bytes = len(payload)
+		result.Response, result.Err = c.LoadOnce(ctx, header.Table, bytes.NewReader(payload), header.Options)
+	default:
+		result.Err = fmt.Errorf("label %s of WAL segment %s is still in state %s", header.Label, id, state)
+	}
+	result.Duration = time.Since(start)
+
+	if result.Err == nil {
+		if err := seg.remove(); err != nil {
+			result.Err = fmt.Errorf("loaded but failed to remove WAL segment: %w", err)
+		}
+	}
+	return result, true
+}
+
+// joinRows builds the payload of a batch from its rows
+func joinRows(rows [][]byte, opts LoadOptions) []byte {
+	var buf bytes.Buffer
+	if opts.Format == FormatJSON {
+		buf.WriteByte('[')
+	}
+	delimiter := []byte(rowDelimiter(opts))
+	for i, row := range rows {
+		if i > 0 {
+			buf.Write(delimiter)
+		}
+		buf.Write(row)
+	}
+	if opts.Format == FormatJSON {
+		buf.WriteByte(']')
+	}
+	return buf.Bytes()
+}
@@ -0,0 +1,140 @@
+package streamload
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestBatchWriter_WAL(t *testing.T) {
+	var mu sync.Mutex
+	var labels []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		labels = append(labels, r.Header.Get("label"))
+		mu.Unlock()
+		w.Write([]byte(`{"Status":"Success"}`))
+	}))
+	defer server.Close()
+
+	wal, err := OpenWAL(t.TempDir(), WALConfig{Sync: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	client := newTestClient(t, server)
+	writer := client.NewBatchWriter("users", LoadOptions{Label: "users"}, BatchConfig{MaxRows: 2, WAL: wal})
+	for _, row := range []string{"1,a", "2,b", "3,c"} {
+		writer.Write([]byte(row))
+	}
+
+	if ids, _ := wal.Segments(); len(ids) != 0 {
+		t.Errorf("segments in use must not be listed, got %v", ids)
+	}
+	entries, _ := os.ReadDir(wal.Path())
+	if len(entries) == 0 {
+		t.Error("expected the buffered rows to be in a segment")
+	}
+
+	if err := writer.Close(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if entries, _ := os.ReadDir(wal.Path()); len(entries) != 0 {
+		t.Errorf("expected the loaded segments to be deleted, got %d files", len(entries))
+	}
+	if len(labels) != 2 || labels[0] == labels[1] || !strings.HasPrefix(labels[0], "users-") {
+		t.Errorf("unexpected labels %v", labels)
+	}
+}
+
+func TestReplayWAL(t *testing.T) {
+	dir := t.TempDir()
+
+	// A process that crashed with one batch buffered and one loaded but not deleted
+	crashed, _ := OpenWAL(dir, WALConfig{})
+	pending, err := crashed.create("users", LoadOptions{Format: FormatJSON, StripOuterArray: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	pending.append([]byte(`{"id":1}`))
+	pending.append([]byte(`{"id":2}`))
+	// A row whose append was interrupted
+	pending.file.Write([]byte{0, 0, 0, 9, 1, 2})
+	pending.seal()
+	loaded, _ := crashed.create("users", LoadOptions{})
+	loaded.append([]byte("3,c"))
+	loaded.seal()
+	empty, _ := crashed.create("users", LoadOptions{})
+	empty.seal()
+
+	var mu sync.Mutex
+	var loads []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/test_db/get_load_state" {
+			state := "UNKNOWN"
+			if r.URL.Query().Get("label") == loaded.label {
+				state = "VISIBLE"
+			}
+			w.Write([]byte(`{"state":"` + state + `","status":"OK"}`))
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		loads = append(loads, r.Header.Get("label")+" "+string(body))
+		mu.Unlock()
+		w.Write([]byte(`{"Status":"Success"}`))
+	}))
+	defer server.Close()
+
+	wal, _ := OpenWAL(dir, WALConfig{})
+	client := newTestClient(t, server)
+	results, err := client.ReplayWAL(context.Background(), wal)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(results) != 2 || results[0].Err != nil || results[1].Err != nil {
+		t.Fatalf("unexpected results %+v", results)
+	}
+	if results[0].Label != pending.label || results[0].Rows != 2 || results[1].Label != loaded.label {
+		t.Errorf("unexpected results %+v", results)
+	}
+	if len(loads) != 1 || loads[0] != pending.label+` [{"id":1},{"id":2}]` {
+		t.Errorf("expected only the pending segment to be loaded, got %q", loads)
+	}
+	if files, _ := filepath.Glob(filepath.Join(dir, "*")); len(files) != 0 {
+		t.Errorf("expected all segments to be deleted, got %v", files)
+	}
+}
+
+func TestReplayWAL_KeepsFailedSegments(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(`{"status":"FAILED","msg":"FE is down"}`))
+	}))
+	defer server.Close()
+
+	wal, _ := OpenWAL(t.TempDir(), WALConfig{})
+	client := newTestClient(t, server)
+	writer := client.NewBatchWriter("users", LoadOptions{}, BatchConfig{WAL: wal})
+	writer.Write([]byte("1,a"))
+	if err := writer.Close(); err == nil {
+		t.Fatal("expected the batch to fail")
+	}
+
+	ids, _ := wal.Segments()
+	if len(ids) != 1 {
+		t.Fatalf("expected the failed segment to be kept, got %v", ids)
+	}
+	results, err := client.ReplayWAL(context.Background(), wal)
+	if err != nil || len(results) != 1 || results[0].Err == nil {
+		t.Fatalf("expected the replay to fail, got %+v, %v", results, err)
+	}
+	if ids, _ := wal.Segments(); len(ids) != 1 {
+		t.Errorf("expected the segment to be kept, got %v", ids)
+	}
+}